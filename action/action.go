@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/observiq/bindplane-op-action/action/state"
@@ -15,11 +16,9 @@ import (
 	"github.com/observiq/bindplane-op-action/internal/client/model"
 	"github.com/observiq/bindplane-op-action/internal/client/version"
 	"github.com/observiq/bindplane-op-action/internal/glob"
-	"github.com/observiq/bindplane-op-action/internal/repo"
+	"github.com/observiq/bindplane-op-action/internal/logging"
 	"gopkg.in/yaml.v3"
 
-	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing/object"
 	"go.uber.org/zap"
 )
 
@@ -60,13 +59,47 @@ func WithBindPlanePassword(p string) Option {
 	}
 }
 
+// WithBindPlaneOIDCTokenURL sets the token endpoint used to exchange GitHub
+// Actions OIDC tokens for BindPlane access tokens
+func WithBindPlaneOIDCTokenURL(u string) Option {
+	return func(a *Action) {
+		a.config.Auth.OIDC.TokenURL = u
+	}
+}
+
+// WithBindPlaneOIDCAudience sets the audience of the GitHub Actions OIDC token
+func WithBindPlaneOIDCAudience(aud string) Option {
+	return func(a *Action) {
+		a.config.Auth.OIDC.Audience = aud
+	}
+}
+
 // WithTLSCACert sets the certificate authority for the BindPlane client
+//
+// Deprecated: use WithTLSCACerts
 func WithTLSCACert(c string) Option {
+	if c == "" {
+		return WithTLSCACerts(nil)
+	}
+	return WithTLSCACerts([]string{c})
+}
+
+// WithTLSCACerts sets the PEM encoded certificate authorities for the
+// BindPlane client. Each may contain several certificates.
+func WithTLSCACerts(cas []string) Option {
 	return func(a *Action) {
-		if c == "" {
+		if len(cas) == 0 {
 			return
 		}
-		a.config.Network.CertificateAuthority = []string{c}
+		a.config.Network.CertificateAuthority = cas
+	}
+}
+
+// WithTLSAppendSystemCertPool adds the certificate authorities to the
+// system certificate pool instead of replacing it
+func WithTLSAppendSystemCertPool(b bool) Option {
+	return func(a *Action) {
+		a.config.Network.AppendSystemCertPool = b
 	}
 }
 
@@ -140,6 +173,48 @@ func WithGithubURL(u string) Option {
 	}
 }
 
+// WithOutputRepositoryURL sets the URL of a separate repository which
+// rendered configurations are written back to. When unset, configurations
+// are written back to the repository set by WithGithubURL.
+func WithOutputRepositoryURL(u string) Option {
+	return func(a *Action) {
+		a.outputRepositoryURL = u
+	}
+}
+
+// WithOutputRepositoryToken sets the token used to authenticate with
+// the output repository
+func WithOutputRepositoryToken(t string) Option {
+	return func(a *Action) {
+		a.outputRepositoryToken = t
+	}
+}
+
+// WithWorkspace sets the path of the existing repository checkout, which
+// is cloned locally instead of over the network when it has the required
+// branch checked out. The checkout itself is not modified.
+func WithWorkspace(dir string) Option {
+	return func(a *Action) {
+		a.workspace = dir
+	}
+}
+
+// WithCloneDepth limits clones to the given number of commits. Zero
+// clones the full history.
+func WithCloneDepth(depth int) Option {
+	return func(a *Action) {
+		a.cloneDepth = depth
+	}
+}
+
+// WithCloneTimeout sets the clone timeout. Defaults to
+// repo.DefaultCloneTimeout when zero.
+func WithCloneTimeout(d time.Duration) Option {
+	return func(a *Action) {
+		a.cloneTimeout = d
+	}
+}
+
 // WithAutoRollout sets the flag to enable auto rollout
 func WithAutoRollout(b bool) Option {
 	return func(a *Action) {
@@ -161,6 +236,49 @@ func WithUserAgent(ua string) Option {
 	}
 }
 
+// WithProxyURL sets the HTTP(S) proxy used by the BindPlane client and git operations
+func WithProxyURL(u string) Option {
+	return func(a *Action) {
+		a.config.Network.Proxy.URL = u
+	}
+}
+
+// WithProxyUsername sets the username used to authenticate to the proxy
+func WithProxyUsername(u string) Option {
+	return func(a *Action) {
+		a.config.Network.Proxy.Username = u
+	}
+}
+
+// WithProxyPassword sets the password used to authenticate to the proxy
+func WithProxyPassword(p string) Option {
+	return func(a *Action) {
+		a.config.Network.Proxy.Password = p
+	}
+}
+
+// WithNoProxy sets the comma separated list of hosts which should not be proxied
+func WithNoProxy(n string) Option {
+	return func(a *Action) {
+		a.config.Network.Proxy.NoProxy = n
+	}
+}
+
+// WithTrace enables logging of each BindPlane API request and
+// response, with credentials and sensitive parameters redacted
+func WithTrace(b bool) Option {
+	return func(a *Action) {
+		a.config.Trace.Enabled = b
+	}
+}
+
+// WithTraceFile sets the file BindPlane API traces are appended to
+func WithTraceFile(p string) Option {
+	return func(a *Action) {
+		a.config.Trace.File = p
+	}
+}
+
 // New creates a new Action with a configured bindPlane client
 func New(logger *zap.Logger, opts ...Option) (*Action, error) {
 	action := &Action{}
@@ -203,32 +321,112 @@ type Action struct {
 	configurationOutputBranch string
 	githubToken               string
 	githubURL                 string
+	outputRepositoryURL       string
+	outputRepositoryToken     string
+	workspace                 string
+	cloneDepth                int
+	cloneTimeout              time.Duration
+	githubAPIURL              string
+	writeBackMode             string
+	writeBackAll              bool
+	configurationOutputFormat string
+	validateRendered          bool
+	redactRendered            bool
+	redactKeyPatterns         []string
+	pushRetries               int
+
+	// masker masks sensitive parameter values in logs
+	masker *logging.Masker
+
+	// pushRetryBackoff is the delay before the first push retry,
+	// doubled for each subsequent retry. Defaults to
+	// defaultPushRetryBackoff when zero.
+	pushRetryBackoff time.Duration
+
+	// Write back commit options
+	commitAuthorName           string
+	commitAuthorEmail          string
+	commitMessageTemplate      string
+	commitSigningKey           string
+	commitSigningKeyPassphrase string
+	sourceCommitSHA            string
 
 	// Config holds the following options:
 	// - Remote URL
 	// - API Key
 	// - Username
 	// - Password
+	// - OIDC token exchange
 	// - Certificate Authority
+	// - Proxy
+	// - Trace
 	config config.Config
 
 	client *client.BindPlane
 
+	// version is the BindPlane server version, set by TestConnection
+	version version.Version
+
 	// State holds the current state of the action
 	state state.State
 }
 
-// TestConnection wraps the BindPlane client's Version method
+// TestConnection wraps the BindPlane client's Version method. The
+// version is retained and used to check feature compatibility.
 func (a *Action) TestConnection() (version.Version, error) {
 	v, err := a.client.Version(context.Background())
 	if err != nil {
 		return version.Version{}, fmt.Errorf("failed to test connection: %w", err)
 	}
+	a.version = v
 	return v, err
 }
 
+// CheckCompatibility returns an error if the BindPlane server does not
+// support a feature the action is configured to use. The server version
+// is only known after TestConnection is called.
+func (a *Action) CheckCompatibility() error {
+	required := []struct {
+		enabled     bool
+		feature     version.Feature
+		description string
+	}{
+		{a.connectorPath != "", version.FeatureConnectors, fmt.Sprintf("%s resources", model.KindConnector)},
+		{a.fleetPath != "", version.FeatureFleets, fmt.Sprintf("%s resources", model.KindFleet)},
+		{a.autoRollout, version.FeatureRollouts, "rollouts"},
+	}
+
+	if _, err := version.ParseSemantic(a.version.Tag); err != nil {
+		a.Logger.Warn(
+			"Unable to determine BindPlane version, skipping compatibility check",
+			zap.String("bindplane_version", a.version.Tag),
+		)
+		return nil
+	}
+
+	errs := []error{}
+	for _, r := range required {
+		if !r.enabled {
+			continue
+		}
+
+		if supported, _ := a.version.Supports(r.feature); !supported {
+			errs = append(errs, fmt.Errorf(
+				"BindPlane %s does not support %s, %s or newer is required",
+				a.version.Tag, r.description, version.Minimums[r.feature],
+			))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 // Run executes the action
 func (a *Action) Run() error {
+	if err := a.CheckCompatibility(); err != nil {
+		return fmt.Errorf("incompatible BindPlane version: %w", err)
+	}
+
 	a.Logger.Info("Applying resources to Bindplane")
 	if err := a.Apply(); err != nil {
 		return fmt.Errorf("failed to apply resources: %w", err)
@@ -237,22 +435,34 @@ func (a *Action) Run() error {
 	if a.autoRollout {
 		a.Logger.Info("Auto rollout enabled, rolling out any pending changes")
 		if err := a.AutoRollout(); err != nil {
-			return fmt.Errorf("failed to rollout configuration: %s", err)
+			return fmt.Errorf("failed to rollout configuration: %w", err)
 		}
 	}
 
 	if a.enableWriteBack {
 		a.Logger.Info("Write back enabled, writing back configuration")
 		if err := a.WriteBack(); err != nil {
-			return fmt.Errorf("failed to write back configuration: %s", err)
+			return fmt.Errorf("failed to write back configuration: %w", err)
 		}
 	}
 
 	return nil
 }
 
-// RunRollout progresses a rollout for a configuration
+// RunRollout progresses a rollout for a configuration. Errors are
+// classified as ErrRollout.
 func (a *Action) RunRollout(config string) error {
+	return classify(ErrRollout, a.runRollout(config))
+}
+
+func (a *Action) runRollout(config string) error {
+	if supported, known := a.version.Supports(version.FeatureRollouts); known && !supported {
+		return fmt.Errorf(
+			"BindPlane %s does not support rollouts, %s or newer is required",
+			a.version.Tag, version.Minimums[version.FeatureRollouts],
+		)
+	}
+
 	if err := a.client.StartRollout(config); err != nil {
 		return fmt.Errorf("start rollout: %w", err)
 	}
@@ -265,102 +475,84 @@ func (a *Action) RunRollout(config string) error {
 // by resource library sources, processors, and connectors. Configurations should be
 // applied next because they reference other resources. Fleets are applied last.
 func (a *Action) Apply() error {
-	if a.destinationPath != "" {
-		a.Logger.Info("Applying resources", zap.String("Kind", string(model.KindDestination)), zap.String("path", a.destinationPath))
-		err := a.applyAll(a.destinationPath)
-		if err != nil {
-			return fmt.Errorf("destinations: %w", err)
-		}
-	} else {
-		a.Logger.Info("No destination path provided, skipping destinations")
-	}
-
-	if a.sourcePath != "" {
-		a.Logger.Info("Applying resources", zap.String("Kind", string(model.KindSource)), zap.String("path", a.sourcePath))
-		err := a.applyAll(a.sourcePath)
-		if err != nil {
-			return fmt.Errorf("sources: %w", err)
+	paths := []struct {
+		kind model.Kind
+		name string
+		path string
+	}{
+		{model.KindDestination, "destinations", a.destinationPath},
+		{model.KindSource, "sources", a.sourcePath},
+		{model.KindProcessor, "processors", a.processorPath},
+		{model.KindConnector, "connectors", a.connectorPath},
+		{model.KindConfiguration, "configuration", a.configurationPath},
+		{model.KindFleet, "fleets", a.fleetPath},
+	}
+
+	// Logs of each kind are grouped, which collapses them
+	// into a section when using the github log format
+	logger := a.Logger
+	defer func() { a.Logger = logger }()
+
+	for _, p := range paths {
+		if p.path == "" {
+			logger.Info(fmt.Sprintf("No %s path provided, skipping %s", strings.ToLower(string(p.kind)), p.name))
+			continue
 		}
-	} else {
-		a.Logger.Info("No source path provided, skipping sources")
-	}
 
-	if a.processorPath != "" {
-		a.Logger.Info("Applying resources", zap.String("Kind", string(model.KindProcessor)), zap.String("path", a.processorPath))
-		err := a.applyAll(a.processorPath)
-		if err != nil {
-			return fmt.Errorf("processors: %w", err)
+		a.Logger = logger.With(logging.Group(p.name))
+		a.Logger.Info("Applying resources", zap.String("Kind", string(p.kind)), zap.String("path", p.path))
+		if err := a.applyAll(p.path); err != nil {
+			return fmt.Errorf("%s: %w", p.name, err)
 		}
-	} else {
-		a.Logger.Info("No processor path provided, skipping processors")
 	}
 
-	if a.connectorPath != "" {
-		a.Logger.Info("Applying resources", zap.String("Kind", string(model.KindConnector)), zap.String("path", a.connectorPath))
-		err := a.applyAll(a.connectorPath)
-		if err != nil {
-			return fmt.Errorf("connectors: %w", err)
-		}
-	} else {
-		a.Logger.Info("No connector path provided, skipping connectors")
-	}
+	return nil
+}
 
-	if a.configurationPath != "" {
-		a.Logger.Info("Applying resources", zap.String("Kind", string(model.KindConfiguration)), zap.String("path", a.configurationPath))
-		err := a.applyAll(a.configurationPath)
-		if err != nil {
-			return fmt.Errorf("configuration: %w", err)
-		}
-	} else {
-		a.Logger.Info("No configuration path provided, skipping configuration")
+// applyAll takes a file or directory path and applies all resources.
+// It recursively walks through all subdirectories and applies YAML files.
+// It also supports glob patterns like "*.yaml" or "./resources/*.yaml".
+func (a *Action) applyAll(path string) error {
+	files, err := a.resourceFiles(path)
+	if err != nil {
+		return err
 	}
 
-	if a.fleetPath != "" {
-		a.Logger.Info("Applying resources", zap.String("Kind", string(model.KindFleet)), zap.String("path", a.fleetPath))
-		err := a.applyAll(a.fleetPath)
-		if err != nil {
-			return fmt.Errorf("fleets: %w", err)
+	for _, file := range files {
+		if err := a.apply(file); err != nil {
+			return &ResourceError{Path: file, Err: err}
 		}
-	} else {
-		a.Logger.Info("No fleet path provided, skipping fleets")
 	}
-
 	return nil
 }
 
-// applyAll takes a file or directory path and applies all resources.
-// It recursively walks through all subdirectories and applies YAML files.
-// It also supports glob patterns like "*.yaml" or "./resources/*.yaml".
-func (a *Action) applyAll(path string) error {
+// resourceFiles returns the resource files at a file, directory or glob
+// path. Directories are walked recursively for YAML files.
+func (a *Action) resourceFiles(path string) ([]string, error) {
 	info, err := os.Stat(path)
 	switch {
 	case err != nil:
 		if glob.ContainsGlobChars(path) {
 			matches, err := filepath.Glob(path)
 			if err != nil {
-				return fmt.Errorf("glob path %s: %w", path, err)
+				return nil, fmt.Errorf("glob path %s: %w", path, err)
 			}
 			if len(matches) == 0 {
-				return fmt.Errorf("no matching files found when globbing %s", path)
+				return nil, fmt.Errorf("no matching files found when globbing %s", path)
 			}
 
-			a.Logger.Info("Applying globbed resources", zap.String("path", path), zap.Int("matches", len(matches)))
-
-			for _, match := range matches {
-				if err := a.apply(match); err != nil {
-					return err
-				}
-			}
-			return nil
+			a.Logger.Info("Found globbed resources", zap.String("path", path), zap.Int("matches", len(matches)))
+			return matches, nil
 		}
-		return fmt.Errorf("stat path %s: %w", path, err)
+		return nil, fmt.Errorf("stat path %s: %w", path, err)
 
 	case !info.IsDir():
-		return a.apply(path)
+		return []string{path}, nil
 
 	default:
 		a.Logger.Info("Walking directory", zap.String("path", path))
 
+		files := []string{}
 		err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
 			if err != nil {
 				a.Logger.Error("Error walking path", zap.String("path", p), zap.Error(err))
@@ -376,13 +568,14 @@ func (a *Action) applyAll(path string) error {
 				return nil
 			}
 
-			return a.apply(p)
+			files = append(files, p)
+			return nil
 		})
 
 		if err != nil {
-			return fmt.Errorf("walk path %s: %w", path, err)
+			return nil, fmt.Errorf("walk path %s: %w", path, err)
 		}
-		return nil
+		return files, nil
 	}
 }
 
@@ -391,8 +584,9 @@ func (a *Action) applyAll(path string) error {
 func (a *Action) apply(path string) error {
 	resources, err := decodeAnyResourceFile(path)
 	if err != nil {
-		return fmt.Errorf("decode resources: %w", err)
+		return classify(ErrInvalidResource, fmt.Errorf("decode resources: %w", err))
 	}
+	a.collectSensitiveValues(resources)
 
 	resp, err := a.client.Apply(context.Background(), resources)
 	if err != nil {
@@ -409,12 +603,7 @@ func (a *Action) apply(path string) error {
 		kind := s.Resource.Kind
 		status := s.Status
 
-		// Attach the configuration resource to the state
-		// so we can use it for auto rollout
-		if kind == string(model.KindConfiguration) {
-			a.state.SetConfiguration(s.Resource.Metadata.Name, s.Resource)
-			a.Logger.Debug("Configuration resource added to state", zap.String("name", name))
-		}
+		a.recordResource(s.Resource)
 
 		switch status {
 		case model.StatusUnchanged, model.StatusConfigured, model.StatusCreated:
@@ -427,11 +616,11 @@ func (a *Action) apply(path string) error {
 			)
 			continue
 		case model.StatusInvalid:
-			return fmt.Errorf("invalid resource: %s: %s", name, s.Reason)
+			return classify(ErrInvalidResource, fmt.Errorf("invalid resource: %s: %s", name, s.Reason))
 		case model.StatusError:
 			return fmt.Errorf("error: %s: %s", name, s.Reason)
 		case model.StatusForbidden:
-			return fmt.Errorf("forbidden: %s: %s", name, s.Reason)
+			return classify(ErrUnauthorized, fmt.Errorf("forbidden: %s: %s", name, s.Reason))
 		default:
 			return fmt.Errorf("unexpected status: %s", status)
 		}
@@ -440,8 +629,25 @@ func (a *Action) apply(path string) error {
 	return nil
 }
 
-// AutoRollout TODO
+// recordResource adds a resource to the state. Configurations are
+// attached so we can use them for auto rollout. Other resources are
+// recorded so write back can find the configurations which reference them.
+func (a *Action) recordResource(r model.AnyResource) {
+	if r.Kind == string(model.KindConfiguration) {
+		a.state.SetConfiguration(r.Metadata.Name, r)
+		a.Logger.Debug("Configuration resource added to state", zap.String("name", r.Metadata.Name))
+		return
+	}
+	a.state.AddResource(model.Kind(r.Kind), r.Metadata.Name)
+}
+
+// AutoRollout starts a rollout for each configuration in the state which
+// has a pending rollout. Errors are classified as ErrRollout.
 func (a *Action) AutoRollout() error {
+	return classify(ErrRollout, a.startPendingRollouts())
+}
+
+func (a *Action) startPendingRollouts() error {
 	configurations := []model.Configuration{}
 	for _, name := range a.state.ConfigurationNames() {
 		configuration, err := a.client.Configuration(context.Background(), name)
@@ -477,102 +683,6 @@ func (a *Action) AutoRollout() error {
 	return nil
 }
 
-func (a *Action) WriteBack() error {
-	a.Logger.Info(
-		"Cloning repository", zap.String("branch", a.configurationOutputBranch),
-	)
-
-	repo, err := repo.CloneRepo(a.githubURL, a.configurationOutputBranch, a.githubToken)
-	if err != nil {
-		return fmt.Errorf("clone repository: %w", err)
-	}
-
-	tree, err := repo.Worktree()
-	if err != nil {
-		return fmt.Errorf("get worktree: %w", err)
-	}
-
-	rawConfigs := make(map[string]string)
-	for _, name := range a.state.ConfigurationNames() {
-		rawConfig, err := a.client.RawConfiguration(context.Background(), name)
-		if err != nil {
-			return fmt.Errorf("get configuration %s: %w", name, err)
-		}
-		if rawConfig == "" {
-			return fmt.Errorf("configuration '%s' is empty: %s", name, BugError)
-		}
-		rawConfigs[name] = rawConfig
-	}
-
-	for name, rawConfig := range rawConfigs {
-		path := fmt.Sprintf("./out_repo/%s/%s.yaml", a.configurationOutputDir, name)
-
-		// Create the directory if it doesn't exist. MkdirAll will return
-		// nil if the directory already exists. Returns an error if something
-		// goes wrong.
-		dir, _ := filepath.Split(path)
-		if err := os.MkdirAll(dir, 0750); err != nil {
-			return fmt.Errorf("create directory %s: %w", dir, err)
-		}
-
-		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 user defined filepath
-		if err != nil {
-			return fmt.Errorf("open file %s: %w", path, err)
-		}
-		defer f.Close()
-
-		if _, err := f.WriteString(rawConfig); err != nil {
-			return fmt.Errorf("write file %s: %w", path, err)
-		}
-
-		a.Logger.Info("Raw configuration written to file", zap.String("name", name), zap.String("path", path))
-	}
-
-	status, err := tree.Status()
-	if err != nil {
-		return fmt.Errorf("get work tree status: %w", err)
-	}
-
-	if status.IsClean() {
-		a.Logger.Info("No changes to write back")
-		return nil
-	}
-
-	a.Logger.Info("Detected changes, writing back to repository")
-	for path := range status {
-		a.Logger.Info("file changed", zap.String("path", path))
-		_, err := tree.Add(path)
-		if err != nil {
-			return fmt.Errorf("git add file %s: %w", path, err)
-		}
-	}
-
-	commitMessage := "Bindplane Action: Update OTEL Configs"
-	commitOptions := &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "bindplane-op-action",
-			Email: "bindplane-op-action",
-			When:  time.Now(),
-		},
-	}
-	_, err = tree.Commit(commitMessage, commitOptions)
-	if err != nil {
-		return fmt.Errorf("commit changes: %w", err)
-	}
-
-	pushOpts := &git.PushOptions{
-		RemoteName: "origin",
-	}
-
-	if err = repo.Push(pushOpts); err != nil {
-		return fmt.Errorf("push changes: %w", err)
-	}
-
-	a.Logger.Info("Changes written back to repository")
-
-	return nil
-}
-
 // decodeAnyResourceFile takes a file path and decodes it into a slice of
 // model.AnyResource. If the file is empty, it will return an error.
 // This function supports globbing, but does not gaurantee ordering. This
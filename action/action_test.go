@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"github.com/observiq/bindplane-op-action/internal/client/version"
 
 	"go.uber.org/zap"
 
@@ -109,6 +110,22 @@ func TestWithBindPlaneUsername(t *testing.T) {
 	}
 }
 
+func TestWithBindPlaneOIDC(t *testing.T) {
+	a := &Action{}
+	WithBindPlaneOIDCTokenURL("https://auth.corp.net/token")(a)
+	WithBindPlaneOIDCAudience("bindplane")(a)
+	require.Equal(t, &Action{
+		config: config.Config{
+			Auth: config.Auth{
+				OIDC: config.OIDC{
+					TokenURL: "https://auth.corp.net/token",
+					Audience: "bindplane",
+				},
+			},
+		},
+	}, a)
+}
+
 func TestWithUserAgent(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -212,6 +229,115 @@ func TestWithTLSCACert(t *testing.T) {
 	}
 }
 
+func TestWithTLSCACerts(t *testing.T) {
+	cases := []struct {
+		name   string
+		intput []string
+		expect *Action
+	}{
+		{
+			"Set certificate authority",
+			[]string{"ca.pem"},
+			&Action{
+				config: config.Config{
+					Network: config.Network{
+						CertificateAuthority: []string{"ca.pem"},
+					},
+				},
+			},
+		},
+		{
+			"Empty certificate authority",
+			nil,
+			&Action{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &Action{}
+			opt := WithTLSCACerts(tc.intput)
+			opt(a)
+			require.Equal(t, tc.expect, a)
+		})
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	cases := []struct {
+		name   string
+		opt    Option
+		expect *Action
+	}{
+		{
+			"Set proxy URL",
+			WithProxyURL("http://proxy:3128"),
+			&Action{
+				config: config.Config{
+					Network: config.Network{
+						Proxy: config.Proxy{URL: "http://proxy:3128"},
+					},
+				},
+			},
+		},
+		{
+			"Set proxy username",
+			WithProxyUsername("user"),
+			&Action{
+				config: config.Config{
+					Network: config.Network{
+						Proxy: config.Proxy{Username: "user"},
+					},
+				},
+			},
+		},
+		{
+			"Set proxy password",
+			WithProxyPassword("pass"),
+			&Action{
+				config: config.Config{
+					Network: config.Network{
+						Proxy: config.Proxy{Password: "pass"},
+					},
+				},
+			},
+		},
+		{
+			"Set no proxy",
+			WithNoProxy("localhost,.corp.net"),
+			&Action{
+				config: config.Config{
+					Network: config.Network{
+						Proxy: config.Proxy{NoProxy: "localhost,.corp.net"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &Action{}
+			tc.opt(a)
+			require.Equal(t, tc.expect, a)
+		})
+	}
+}
+
+func TestWithTrace(t *testing.T) {
+	a := &Action{}
+	WithTrace(true)(a)
+	WithTraceFile("trace.jsonl")(a)
+	require.Equal(t, &Action{
+		config: config.Config{
+			Trace: config.Trace{
+				Enabled: true,
+				File:    "trace.jsonl",
+			},
+		},
+	}, a)
+}
+
 func TestWithDestinationPath(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -660,3 +786,67 @@ func TestDecodeAnyResourceFileGlobMatchOne(t *testing.T) {
 		require.Contains(t, platforms, v, "Expected platform label to be one of %v, got %s", platforms, v)
 	}
 }
+
+func TestCheckCompatibility(t *testing.T) {
+	cases := []struct {
+		name    string
+		action  *Action
+		tag     string
+		errStrs []string
+	}{
+		{
+			"Supported",
+			&Action{connectorPath: "connectors", fleetPath: "fleets", autoRollout: true},
+			"v1.91.0",
+			nil,
+		},
+		{
+			"Unknown version",
+			&Action{connectorPath: "connectors", fleetPath: "fleets"},
+			"",
+			nil,
+		},
+		{
+			"Connectors and fleets unsupported",
+			&Action{connectorPath: "connectors", fleetPath: "fleets", destinationPath: "destinations"},
+			"v1.59.0",
+			[]string{
+				"BindPlane v1.59.0 does not support Connector resources, v1.83.0 or newer is required",
+				"BindPlane v1.59.0 does not support Fleet resources, v1.91.0 or newer is required",
+			},
+		},
+		{
+			"Fleets unsupported",
+			&Action{connectorPath: "connectors", fleetPath: "fleets"},
+			"v1.83.0",
+			[]string{
+				"BindPlane v1.83.0 does not support Fleet resources, v1.91.0 or newer is required",
+			},
+		},
+		{
+			"Rollouts unsupported",
+			&Action{autoRollout: true},
+			"v1.50.0",
+			[]string{
+				"BindPlane v1.50.0 does not support rollouts, v1.55.0 or newer is required",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.action.Logger = zap.NewNop()
+			tc.action.version = version.Version{Tag: tc.tag}
+
+			err := tc.action.CheckCompatibility()
+			if len(tc.errStrs) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, s := range tc.errStrs {
+				require.Contains(t, err.Error(), s)
+			}
+		})
+	}
+}
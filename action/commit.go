@@ -0,0 +1,159 @@
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/observiq/bindplane-op-action/internal/signing"
+)
+
+const (
+	// DefaultCommitAuthorName is the write back commit author name
+	// used when one is not configured
+	DefaultCommitAuthorName = "bindplane-op-action"
+
+	// DefaultCommitAuthorEmail is the write back commit author email
+	// used when one is not configured
+	DefaultCommitAuthorEmail = "bindplane-op-action"
+
+	// DefaultCommitMessageTemplate is the write back commit message
+	// template used when one is not configured
+	DefaultCommitMessageTemplate = "Bindplane Action: Update OTEL Configs"
+)
+
+// CommitMessageData is the data available to the write back commit
+// message template
+type CommitMessageData struct {
+	// Configurations are the sorted names of the configurations
+	// which changed
+	Configurations []string
+
+	// SHA is the commit which triggered the action
+	SHA string
+
+	// Branch is the branch the commit is pushed to
+	Branch string
+}
+
+// ParseCommitMessageTemplate parses a write back commit message template.
+// The template is executed with CommitMessageData, and the join function
+// is available for formatting configuration names.
+func ParseCommitMessageTemplate(text string) (*template.Template, error) {
+	return template.New("commit_message").
+		Funcs(template.FuncMap{"join": strings.Join}).
+		Option("missingkey=error").
+		Parse(text)
+}
+
+// WithCommitAuthorName sets the write back commit author name
+func WithCommitAuthorName(name string) Option {
+	return func(a *Action) {
+		a.commitAuthorName = name
+	}
+}
+
+// WithCommitAuthorEmail sets the write back commit author email
+func WithCommitAuthorEmail(email string) Option {
+	return func(a *Action) {
+		a.commitAuthorEmail = email
+	}
+}
+
+// WithCommitMessageTemplate sets the Go template used to
+// render the write back commit message
+func WithCommitMessageTemplate(tmpl string) Option {
+	return func(a *Action) {
+		a.commitMessageTemplate = tmpl
+	}
+}
+
+// WithCommitSigningKey sets the armored PGP or SSH private
+// key used to sign write back commits
+func WithCommitSigningKey(key string) Option {
+	return func(a *Action) {
+		a.commitSigningKey = key
+	}
+}
+
+// WithCommitSigningKeyPassphrase sets the passphrase used to
+// decrypt the commit signing key
+func WithCommitSigningKeyPassphrase(passphrase string) Option {
+	return func(a *Action) {
+		a.commitSigningKeyPassphrase = passphrase
+	}
+}
+
+// WithSourceCommitSHA sets the commit which triggered the action,
+// available to the commit message template as .SHA
+func WithSourceCommitSHA(sha string) Option {
+	return func(a *Action) {
+		a.sourceCommitSHA = sha
+	}
+}
+
+// commitOptions returns the options used to create the write back commit
+func (a *Action) commitOptions() (*git.CommitOptions, error) {
+	name := a.commitAuthorName
+	if name == "" {
+		name = DefaultCommitAuthorName
+	}
+
+	email := a.commitAuthorEmail
+	if email == "" {
+		email = DefaultCommitAuthorEmail
+	}
+
+	opts := &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  name,
+			Email: email,
+			When:  time.Now(),
+		},
+	}
+
+	if a.commitSigningKey != "" {
+		signer, err := signing.New(a.commitSigningKey, a.commitSigningKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("commit signing key: %w", err)
+		}
+		opts.Signer = signer
+	}
+
+	return opts, nil
+}
+
+// commitMessage renders the write back commit message for the changed
+// configurations
+func (a *Action) commitMessage(branch string, changed []string) (string, error) {
+	text := a.commitMessageTemplate
+	if text == "" {
+		text = DefaultCommitMessageTemplate
+	}
+
+	tmpl, err := ParseCommitMessageTemplate(text)
+	if err != nil {
+		return "", fmt.Errorf("parse commit message template: %w", err)
+	}
+
+	data := CommitMessageData{
+		Configurations: changed,
+		SHA:            a.sourceCommitSHA,
+		Branch:         branch,
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("render commit message template: %w", err)
+	}
+
+	message := strings.TrimSpace(b.String())
+	if message == "" {
+		return "", fmt.Errorf("commit message template rendered an empty message")
+	}
+	return message, nil
+}
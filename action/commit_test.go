@@ -0,0 +1,117 @@
+package action
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCommitMessage(t *testing.T) {
+	cases := []struct {
+		name      string
+		template  string
+		expected  string
+		expectErr string
+	}{
+		{
+			name:     "default",
+			expected: DefaultCommitMessageTemplate,
+		},
+		{
+			name:     "template",
+			template: "Render {{ join .Configurations \", \" }} from {{ .SHA }} to {{ .Branch }}",
+			expected: "Render gateway, node from abc123 to main",
+		},
+		{
+			name:     "range",
+			template: "Update configs\n\n{{ range .Configurations }}- {{ . }}\n{{ end }}",
+			expected: "Update configs\n\n- gateway\n- node",
+		},
+		{
+			name:      "invalid",
+			template:  "{{ .SHA",
+			expectErr: "parse commit message template",
+		},
+		{
+			name:      "unknown-field",
+			template:  "{{ .Missing }}",
+			expectErr: "render commit message template",
+		},
+		{
+			name:      "empty",
+			template:  "{{ if false }}x{{ end }}",
+			expectErr: "empty message",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &Action{}
+			WithCommitMessageTemplate(tc.template)(a)
+			WithSourceCommitSHA("abc123")(a)
+
+			message, err := a.commitMessage("main", []string{"gateway", "node"})
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, message)
+		})
+	}
+}
+
+func TestWriteBackCommitOptions(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+
+	remote := newTestRemote(t, "main", nil)
+	server := newTestBindPlane(t, map[string]string{
+		"gateway": "receivers: {}\n",
+	})
+
+	a := newTestAction(t, server, []string{"gateway"},
+		WithOTELConfigWriteBack(true),
+		WithConfigurationOutputDir("otel"),
+		WithConfigurationOutputBranch("main"),
+		WithGithubURL(remote),
+		WithCommitAuthorName("Config Bot"),
+		WithCommitAuthorEmail("bot@example.com"),
+		WithCommitMessageTemplate("Render {{ join .Configurations \", \" }} for {{ .SHA }}"),
+		WithCommitSigningKey(string(pem.EncodeToMemory(block))),
+		WithSourceCommitSHA("abc123"),
+	)
+
+	require.NoError(t, a.WriteBack())
+
+	commit := headCommit(t, remote, "main")
+	require.Equal(t, "Config Bot", commit.Author.Name)
+	require.Equal(t, "bot@example.com", commit.Author.Email)
+	require.Equal(t, "Render gateway for abc123", commit.Message)
+	require.Contains(t, commit.PGPSignature, "-----BEGIN SSH SIGNATURE-----")
+}
+
+func TestWriteBackInvalidSigningKey(t *testing.T) {
+	remote := newTestRemote(t, "main", nil)
+	server := newTestBindPlane(t, map[string]string{
+		"gateway": "receivers: {}\n",
+	})
+
+	a := newTestAction(t, server, []string{"gateway"},
+		WithOTELConfigWriteBack(true),
+		WithConfigurationOutputDir("otel"),
+		WithConfigurationOutputBranch("main"),
+		WithGithubURL(remote),
+		WithCommitSigningKey("invalid"),
+	)
+
+	head := headCommit(t, remote, "main")
+	require.ErrorContains(t, a.WriteBack(), "commit signing key")
+	require.Equal(t, head.Hash, headCommit(t, remote, "main").Hash)
+}
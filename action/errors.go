@@ -0,0 +1,152 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/observiq/bindplane-op-action/internal/client"
+)
+
+// ResourceError is an error reading or applying the resources of a file
+type ResourceError struct {
+	// Path is the resource file
+	Path string
+	Err  error
+}
+
+// Error returns the message of the underlying error. The path is not
+// included because decode errors already name the file.
+func (e *ResourceError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ResourceError) Unwrap() error {
+	return e.Err
+}
+
+// File returns the path of the resource file. It is used to annotate
+// the file when logging with the github format.
+func (e *ResourceError) File() string {
+	return e.Path
+}
+
+// Error classes returned by the action. Errors are matched to a class
+// with errors.Is, and ExitCode maps each class to an exit code.
+var (
+	// ErrInvalidResource is returned when a resource file cannot be
+	// decoded or BindPlane rejects a resource as invalid
+	ErrInvalidResource = errors.New("invalid resource")
+
+	// ErrUnauthorized is returned when BindPlane rejects the
+	// credentials or does not permit the request
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrConflict is returned when a change conflicts with a concurrent
+	// change, such as a write back push rejected after every retry
+	ErrConflict = errors.New("conflict")
+
+	// ErrRollout is returned when a rollout cannot be started
+	ErrRollout = errors.New("rollout failed")
+
+	// ErrWriteBack is returned when configurations cannot be written back
+	ErrWriteBack = errors.New("write back failed")
+
+	// ErrTimeout is returned when a request or clone times out
+	ErrTimeout = errors.New("timeout")
+
+	// ErrDrift is returned when resources in BindPlane differ
+	// from the resource files
+	ErrDrift = errors.New("drift detected")
+)
+
+// Exit codes of each error class. ExitCodeError is used for
+// errors which do not belong to a class.
+const (
+	ExitCodeError           = 1
+	ExitCodeInvalidResource = 2
+	ExitCodeUnauthorized    = 3
+	ExitCodeConflict        = 4
+	ExitCodeRollout         = 5
+	ExitCodeWriteBack       = 6
+	ExitCodeTimeout         = 7
+	ExitCodeDrift           = 8
+)
+
+// Exit codes of failures which happen before the action runs
+const (
+	ExitCodeParseArgs      = 100
+	ExitCodeValidation     = 101
+	ExitCodeClientInit     = 102
+	ExitCodeTestConnection = 103
+	ExitCodeLoggerInit     = 104
+)
+
+// classifiedError adds a class to an error without changing its message
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.err, e.class}
+}
+
+// classify returns err with the class added, or nil if err is nil
+func classify(class, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{class: class, err: err}
+}
+
+// ExitCode returns the exit code of an error. The cause of an error takes
+// precedence over the step which failed, so a rollout which fails because
+// the API key was rejected exits with ExitCodeUnauthorized.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var apiErr *client.APIError
+	status := 0
+	if errors.As(err, &apiErr) {
+		status = apiErr.StatusCode
+	}
+
+	switch {
+	case isTimeout(err):
+		return ExitCodeTimeout
+	case errors.Is(err, ErrUnauthorized), status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return ExitCodeUnauthorized
+	case errors.Is(err, ErrConflict), status == http.StatusConflict:
+		return ExitCodeConflict
+	case errors.Is(err, ErrInvalidResource), status == http.StatusBadRequest, status == http.StatusUnprocessableEntity:
+		return ExitCodeInvalidResource
+	case errors.Is(err, ErrRollout):
+		return ExitCodeRollout
+	case errors.Is(err, ErrWriteBack):
+		return ExitCodeWriteBack
+	case errors.Is(err, ErrDrift):
+		return ExitCodeDrift
+	default:
+		return ExitCodeError
+	}
+}
+
+// isTimeout returns true if the error was caused by a timeout
+func isTimeout(err error) bool {
+	if errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,55 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/client"
+	"github.com/stretchr/testify/require"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "nil", err: nil, expected: 0},
+		{name: "unclassified", err: errors.New("boom"), expected: ExitCodeError},
+		{name: "invalid resource", err: fmt.Errorf("destinations: %w", classify(ErrInvalidResource, errors.New("invalid resource: otlp: bad"))), expected: ExitCodeInvalidResource},
+		{name: "bad request", err: &client.APIError{StatusCode: http.StatusBadRequest}, expected: ExitCodeInvalidResource},
+		{name: "unauthorized", err: fmt.Errorf("client error: %w", &client.APIError{StatusCode: http.StatusUnauthorized}), expected: ExitCodeUnauthorized},
+		{name: "forbidden resource", err: classify(ErrUnauthorized, errors.New("forbidden: otlp")), expected: ExitCodeUnauthorized},
+		{name: "conflict", err: &client.APIError{StatusCode: http.StatusConflict}, expected: ExitCodeConflict},
+		{name: "rollout", err: classify(ErrRollout, errors.New("start rollout")), expected: ExitCodeRollout},
+		{name: "rollout unauthorized", err: classify(ErrRollout, &client.APIError{StatusCode: http.StatusForbidden}), expected: ExitCodeUnauthorized},
+		{name: "write back", err: classify(ErrWriteBack, errors.New("commit changes")), expected: ExitCodeWriteBack},
+		{name: "write back push conflict", err: classify(ErrWriteBack, classify(ErrConflict, errors.New("push changes"))), expected: ExitCodeConflict},
+		{name: "deadline exceeded", err: classify(ErrWriteBack, fmt.Errorf("clone repository: %w", context.DeadlineExceeded)), expected: ExitCodeTimeout},
+		{name: "drift", err: classify(ErrDrift, errors.New("2 resources drifted")), expected: ExitCodeDrift},
+		{name: "network timeout", err: fmt.Errorf("failed to get version: %w", timeoutError{}), expected: ExitCodeTimeout},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, ExitCode(tc.err))
+		})
+	}
+}
+
+func TestClassifyKeepsMessage(t *testing.T) {
+	require.NoError(t, classify(ErrRollout, nil))
+
+	err := classify(ErrRollout, errors.New("start rollout: not found"))
+	require.EqualError(t, err, "start rollout: not found")
+	require.ErrorIs(t, err, ErrRollout)
+}
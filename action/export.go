@@ -0,0 +1,211 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"github.com/observiq/bindplane-op-action/internal/glob"
+	"github.com/observiq/bindplane-op-action/internal/logging"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// DriftReason describes how a resource differs between
+// the resource files and BindPlane
+type DriftReason string
+
+const (
+	// DriftMissing is a resource in the resource files which does not exist in BindPlane
+	DriftMissing DriftReason = "missing"
+
+	// DriftModified is a resource which differs from the resource files
+	DriftModified DriftReason = "modified"
+
+	// DriftUntracked is a resource in BindPlane which is not in the resource files
+	DriftUntracked DriftReason = "untracked"
+)
+
+// Drift is a resource which differs between the resource files and BindPlane
+type Drift struct {
+	Kind model.Kind
+	Name string
+
+	// Path is the file the resource was read from. It is empty
+	// for untracked resources.
+	Path string
+
+	Reason DriftReason
+}
+
+// Export writes the resources in BindPlane to the configured resource
+// paths and returns the files written. Resources are written to a file
+// per resource named after the resource when the path is a directory or
+// ends with a slash, otherwise every resource of the kind is written to
+// the path. Existing files are overwritten. Glob paths are not supported.
+func (a *Action) Export() ([]string, error) {
+	written := []string{}
+	for _, p := range a.resourcePaths() {
+		if glob.ContainsGlobChars(p.path) {
+			return nil, fmt.Errorf("%s path %s: cannot export to a glob pattern", p.kind, p.path)
+		}
+
+		resources, err := a.client.Resources(context.Background(), p.kind)
+		if err != nil {
+			return nil, fmt.Errorf("list %s resources: %w", p.kind, err)
+		}
+
+		exported := make([]*model.AnyResource, 0, len(resources))
+		for _, r := range resources {
+			if r != nil {
+				exported = append(exported, exportResource(r))
+			}
+		}
+		sort.Slice(exported, func(i, j int) bool {
+			return exported[i].Metadata.Name < exported[j].Metadata.Name
+		})
+
+		files, err := writeExport(p.path, exported)
+		if err != nil {
+			return nil, fmt.Errorf("%s path %s: %w", p.kind, p.path, err)
+		}
+
+		a.Logger.Info("Exported resources",
+			zap.String("kind", string(p.kind)),
+			zap.String("path", p.path),
+			zap.Int("count", len(exported)),
+		)
+		written = append(written, files...)
+	}
+	return written, nil
+}
+
+// exportResource returns the fields of a resource which are kept in
+// resource files, dropping those set by BindPlane such as the ID and version
+func exportResource(r *model.AnyResource) *model.AnyResource {
+	return &model.AnyResource{
+		ResourceMeta: model.ResourceMeta{
+			APIVersion: r.APIVersion,
+			Kind:       r.Kind,
+			Metadata: model.Metadata{
+				Name:        r.Metadata.Name,
+				DisplayName: r.Metadata.DisplayName,
+				Description: r.Metadata.Description,
+				Labels:      r.Metadata.Labels,
+			},
+		},
+		Spec: r.Spec,
+	}
+}
+
+// writeExport writes the resources to path and returns the files written
+func writeExport(path string, resources []*model.AnyResource) ([]string, error) {
+	info, err := os.Stat(path)
+	isDir := (err == nil && info.IsDir()) || strings.HasSuffix(path, "/")
+
+	if !isDir {
+		if err := writeResourceFile(path, resources); err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	}
+
+	files := make([]string, 0, len(resources))
+	for _, r := range resources {
+		file := filepath.Join(path, r.Metadata.Name+".yaml")
+		if err := writeResourceFile(file, []*model.AnyResource{r}); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// writeResourceFile writes the resources to a file as YAML documents
+func writeResourceFile(path string, resources []*model.AnyResource) error {
+	b := bytes.Buffer{}
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	for _, r := range resources {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("encode %s %s: %w", r.Kind, r.Metadata.Name, err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encode %s: %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("create directory %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0600); err != nil {
+		return fmt.Errorf("write file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Drift compares the resources of the configured resource paths with
+// BindPlane. Resources which would be changed by Apply are missing or
+// modified, and resources of a configured kind which exist in BindPlane
+// but not in the resource files are untracked. ErrDrift is returned
+// along with the drift when any is found.
+func (a *Action) Drift() ([]Drift, error) {
+	changes, err := a.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	drift := []Drift{}
+	local := map[model.Kind]map[string]bool{}
+	for _, c := range changes {
+		if local[c.Kind] == nil {
+			local[c.Kind] = map[string]bool{}
+		}
+		local[c.Kind][c.Name] = true
+
+		switch c.Status {
+		case model.StatusCreated:
+			drift = append(drift, Drift{Kind: c.Kind, Name: c.Name, Path: c.Path, Reason: DriftMissing})
+		case model.StatusConfigured:
+			drift = append(drift, Drift{Kind: c.Kind, Name: c.Name, Path: c.Path, Reason: DriftModified})
+		}
+	}
+
+	for _, p := range a.resourcePaths() {
+		resources, err := a.client.Resources(context.Background(), p.kind)
+		if err != nil {
+			return nil, fmt.Errorf("list %s resources: %w", p.kind, err)
+		}
+
+		names := []string{}
+		for _, r := range resources {
+			if r != nil && !local[p.kind][r.Metadata.Name] {
+				names = append(names, r.Metadata.Name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			drift = append(drift, Drift{Kind: p.kind, Name: name, Reason: DriftUntracked})
+		}
+	}
+
+	for _, d := range drift {
+		a.Logger.Warn("Resource drifted",
+			zap.String("kind", string(d.Kind)),
+			zap.String("name", d.Name),
+			zap.String("reason", string(d.Reason)),
+			zap.String(logging.FileKey, d.Path),
+		)
+	}
+
+	if len(drift) > 0 {
+		return drift, classify(ErrDrift, fmt.Errorf("%d resources drifted from the resource files", len(drift)))
+	}
+	return drift, nil
+}
@@ -0,0 +1,130 @@
+package action
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// exportServer returns a server which lists the destinations of TestPlan,
+// plus an untracked destination, and returns each by name
+func exportServer(t *testing.T) *httptest.Server {
+	destinations := map[string]string{
+		"gateway": `{"kind": "Destination", "apiVersion": "bindplane.observiq.com/v1", "metadata": {"id": "1", "name": "gateway", "version": 3},
+			"spec": {"type": "otlp_grpc", "parameters": [{"name": "hostname", "value": "gateway.example.com"}]}}`,
+		"logging": `{"kind": "Destination", "apiVersion": "bindplane.observiq.com/v1", "metadata": {"name": "logging"}, "spec": {"type": "custom"}}`,
+		"archive": `{"kind": "Destination", "apiVersion": "bindplane.observiq.com/v1", "metadata": {"name": "archive", "labels": {"team": "ops"}}, "spec": {"type": "s3"}}`,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/destinations", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"destinations": [` + destinations["logging"] + `,` + destinations["gateway"] + `,` + destinations["archive"] + `]}`))
+	})
+	mux.HandleFunc("GET /v1/destinations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := destinations[r.PathValue("name")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"destination": ` + body + `}`))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestExport(t *testing.T) {
+	server := exportServer(t)
+	defer server.Close()
+
+	cases := []struct {
+		name        string
+		path        func(dir string) string
+		expectFiles []string
+		expectErr   string
+	}{
+		{
+			name:        "file",
+			path:        func(dir string) string { return filepath.Join(dir, "destinations.yaml") },
+			expectFiles: []string{"destinations.yaml"},
+		},
+		{
+			name:        "directory",
+			path:        func(dir string) string { return filepath.Join(dir, "destinations") + "/" },
+			expectFiles: []string{"destinations/archive.yaml", "destinations/gateway.yaml", "destinations/logging.yaml"},
+		},
+		{
+			name:      "glob",
+			path:      func(dir string) string { return filepath.Join(dir, "*.yaml") },
+			expectErr: "cannot export to a glob pattern",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			a, err := New(zap.NewNop(),
+				WithBindPlaneRemoteURL(server.URL),
+				WithDestinationPath(tc.path(dir)),
+			)
+			require.NoError(t, err)
+
+			files, err := a.Export()
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+
+			expected := make([]string, 0, len(tc.expectFiles))
+			for _, f := range tc.expectFiles {
+				expected = append(expected, filepath.Join(dir, f))
+			}
+			require.Equal(t, expected, files)
+
+			// Exported files can be decoded, and fields set by BindPlane are dropped
+			resources := []*model.AnyResource{}
+			for _, f := range files {
+				decoded, err := decodeAnyResourceFile(f)
+				require.NoError(t, err)
+				resources = append(resources, decoded...)
+			}
+			require.Len(t, resources, 3)
+			require.Equal(t, "archive", resources[0].Metadata.Name)
+			require.Equal(t, map[string]string{"team": "ops"}, resources[0].Metadata.Labels)
+			require.Equal(t, "gateway", resources[1].Metadata.Name)
+			require.Empty(t, resources[1].Metadata.ID)
+			require.Zero(t, resources[1].Metadata.Version)
+		})
+	}
+}
+
+func TestDrift(t *testing.T) {
+	server := exportServer(t)
+	defer server.Close()
+
+	dir := t.TempDir()
+	destinations := filepath.Join(dir, "destinations.yaml")
+	require.NoError(t, os.WriteFile(destinations, []byte(planDestinations), 0600))
+
+	a, err := New(zap.NewNop(),
+		WithBindPlaneRemoteURL(server.URL),
+		WithDestinationPath(destinations),
+	)
+	require.NoError(t, err)
+
+	drift, err := a.Drift()
+	require.ErrorIs(t, err, ErrDrift)
+	require.Equal(t, ExitCodeDrift, ExitCode(err))
+	require.Equal(t, []Drift{
+		{Kind: model.KindDestination, Name: "logging", Path: destinations, Reason: DriftModified},
+		{Kind: model.KindDestination, Name: "new", Path: destinations, Reason: DriftMissing},
+		{Kind: model.KindDestination, Name: "archive", Reason: DriftUntracked},
+	}, drift)
+}
@@ -0,0 +1,166 @@
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// OutputFormatRaw writes each configuration as <name>.yaml
+	OutputFormatRaw = "raw"
+
+	// OutputFormatConfigMap writes each configuration as a Kubernetes
+	// ConfigMap manifest named after the configuration, <name>.yaml
+	OutputFormatConfigMap = "configmap"
+
+	// OutputFormatHelm writes each configuration as a Helm values file,
+	// <name>.values.yaml, with the configuration under the config key
+	OutputFormatHelm = "helm"
+
+	// OutputFormatSplit writes each configuration to a directory, <name>/,
+	// with a file for each top level section such as receivers.yaml
+	OutputFormatSplit = "split"
+)
+
+// OutputFormats are the supported configuration output formats
+var OutputFormats = []string{OutputFormatRaw, OutputFormatConfigMap, OutputFormatHelm, OutputFormatSplit}
+
+const (
+	// configMapKey is the ConfigMap data key holding the configuration
+	configMapKey = "config.yaml"
+
+	// helmValuesKey is the Helm values key holding the configuration,
+	// matching the OpenTelemetry Collector Helm chart
+	helmValuesKey = "config"
+)
+
+// WithConfigurationOutputFormat sets the format used when writing
+// rendered configurations. Defaults to OutputFormatRaw.
+func WithConfigurationOutputFormat(f string) Option {
+	return func(a *Action) {
+		a.configurationOutputFormat = f
+	}
+}
+
+// renderOutputs returns the files to write for a configuration in the
+// given format. Files are keyed by their slash separated path, relative
+// to the configuration output directory.
+func renderOutputs(format, name, rawConfig string) (map[string][]byte, error) {
+	switch format {
+	case "", OutputFormatRaw:
+		return map[string][]byte{name + ".yaml": []byte(rawConfig)}, nil
+	case OutputFormatConfigMap:
+		return renderConfigMap(name, rawConfig)
+	case OutputFormatHelm:
+		return renderHelmValues(name, rawConfig)
+	case OutputFormatSplit:
+		return renderSplit(name, rawConfig)
+	default:
+		return nil, fmt.Errorf("unknown configuration output format %q", format)
+	}
+}
+
+// renderConfigMap renders the configuration as a Kubernetes ConfigMap
+func renderConfigMap(name, rawConfig string) (map[string][]byte, error) {
+	configMap := struct {
+		APIVersion string `yaml:"apiVersion"`
+		Kind       string `yaml:"kind"`
+		Metadata   struct {
+			Name string `yaml:"name"`
+		} `yaml:"metadata"`
+		Data map[string]string `yaml:"data"`
+	}{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Data:       map[string]string{configMapKey: rawConfig},
+	}
+	configMap.Metadata.Name = name
+
+	b, err := marshalYAML(configMap)
+	if err != nil {
+		return nil, fmt.Errorf("marshal configmap for configuration %s: %w", name, err)
+	}
+	return map[string][]byte{name + ".yaml": b}, nil
+}
+
+// renderHelmValues renders the configuration as a Helm values file
+func renderHelmValues(name, rawConfig string) (map[string][]byte, error) {
+	config, err := parseRawConfiguration(name, rawConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	values := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: helmValuesKey},
+			config,
+		},
+	}
+
+	b, err := marshalYAML(values)
+	if err != nil {
+		return nil, fmt.Errorf("marshal helm values for configuration %s: %w", name, err)
+	}
+	return map[string][]byte{name + ".values.yaml": b}, nil
+}
+
+// renderSplit renders each top level section of the configuration,
+// such as receivers, processors, exporters and service, to its own file
+func renderSplit(name, rawConfig string) (map[string][]byte, error) {
+	config, err := parseRawConfiguration(name, rawConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(config.Content)/2)
+	for i := 0; i+1 < len(config.Content); i += 2 {
+		key, value := config.Content[i], config.Content[i+1]
+
+		section := &yaml.Node{
+			Kind:    yaml.MappingNode,
+			Content: []*yaml.Node{key, value},
+		}
+
+		b, err := marshalYAML(section)
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s for configuration %s: %w", key.Value, name, err)
+		}
+		files[path.Join(name, key.Value+".yaml")] = b
+	}
+
+	return files, nil
+}
+
+// parseRawConfiguration parses a rendered configuration, which
+// must be a YAML mapping
+func parseRawConfiguration(name, rawConfig string) (*yaml.Node, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(rawConfig), doc); err != nil {
+		return nil, fmt.Errorf("configuration %s is malformed, failed to unmarshal yaml: %w", name, err)
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("configuration %s is not a yaml mapping", name)
+	}
+
+	return doc.Content[0], nil
+}
+
+// marshalYAML marshals v with the two space indentation used
+// by rendered configurations
+func marshalYAML(v any) ([]byte, error) {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
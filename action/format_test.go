@@ -0,0 +1,139 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testRawConfig = `receivers:
+  otlp:
+    protocols:
+      grpc: {}
+processors:
+  batch: {}
+exporters:
+  logging: {}
+service:
+  pipelines:
+    logs:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [logging]
+`
+
+func TestRenderOutputs(t *testing.T) {
+	cases := []struct {
+		name      string
+		format    string
+		raw       string
+		expected  map[string]string
+		expectErr string
+	}{
+		{
+			name:     "default",
+			raw:      testRawConfig,
+			expected: map[string]string{"gateway.yaml": testRawConfig},
+		},
+		{
+			name:     "raw",
+			format:   OutputFormatRaw,
+			raw:      "not: [valid",
+			expected: map[string]string{"gateway.yaml": "not: [valid"},
+		},
+		{
+			name:   "configmap",
+			format: OutputFormatConfigMap,
+			raw:    "receivers: {}\n",
+			expected: map[string]string{
+				"gateway.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: gateway\ndata:\n  config.yaml: |\n    receivers: {}\n",
+			},
+		},
+		{
+			name:   "helm",
+			format: OutputFormatHelm,
+			raw:    "receivers:\n  otlp: {}\nexporters:\n  logging: {}\n",
+			expected: map[string]string{
+				"gateway.values.yaml": "config:\n  receivers:\n    otlp: {}\n  exporters:\n    logging: {}\n",
+			},
+		},
+		{
+			name:   "split",
+			format: OutputFormatSplit,
+			raw:    testRawConfig,
+			expected: map[string]string{
+				"gateway/receivers.yaml":  "receivers:\n  otlp:\n    protocols:\n      grpc: {}\n",
+				"gateway/processors.yaml": "processors:\n  batch: {}\n",
+				"gateway/exporters.yaml":  "exporters:\n  logging: {}\n",
+				"gateway/service.yaml":    "service:\n  pipelines:\n    logs:\n      receivers: [otlp]\n      processors: [batch]\n      exporters: [logging]\n",
+			},
+		},
+		{
+			name:      "split-malformed",
+			format:    OutputFormatSplit,
+			raw:       "not: [valid",
+			expectErr: "configuration gateway is malformed",
+		},
+		{
+			name:      "helm-not-mapping",
+			format:    OutputFormatHelm,
+			raw:       "- receivers\n",
+			expectErr: "configuration gateway is not a yaml mapping",
+		},
+		{
+			name:      "unknown",
+			format:    "json",
+			raw:       testRawConfig,
+			expectErr: `unknown configuration output format "json"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			outputs, err := renderOutputs(tc.format, "gateway", tc.raw)
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+
+			actual := make(map[string]string, len(outputs))
+			for file, b := range outputs {
+				actual[file] = string(b)
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestWriteBackOutputFormat(t *testing.T) {
+	remote := newTestRemote(t, "main", nil)
+	server := newTestBindPlane(t, map[string]string{
+		"gateway": "receivers:\n  otlp: {}\nservice: {}\n",
+	})
+
+	opts := []Option{
+		WithOTELConfigWriteBack(true),
+		WithConfigurationOutputDir("otel"),
+		WithConfigurationOutputBranch("main"),
+		WithGithubURL(remote),
+	}
+
+	a := newTestAction(t, server, []string{"gateway"}, opts...)
+	require.NoError(t, a.WriteBack())
+	require.Contains(t, remoteFiles(t, remote, "main"), "otel/gateway.yaml")
+
+	// Changing the format replaces the previous outputs
+	a = newTestAction(t, server, []string{"gateway"}, append(opts, WithConfigurationOutputFormat(OutputFormatSplit))...)
+	require.NoError(t, a.WriteBack())
+
+	files := remoteFiles(t, remote, "main")
+	require.NotContains(t, files, "otel/gateway.yaml")
+	require.Equal(t, "receivers:\n  otlp: {}\n", files["otel/gateway/receivers.yaml"])
+	require.Equal(t, "service: {}\n", files["otel/gateway/service.yaml"])
+
+	m := manifest{}
+	require.NoError(t, yaml.Unmarshal([]byte(files["otel/"+manifestFile]), &m))
+	require.Equal(t, []string{"gateway/receivers.yaml", "gateway/service.yaml"}, m.Configurations["gateway"])
+}
@@ -0,0 +1,61 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// manifestFile is written to the configuration output directory. It records
+// the files owned by the action so stale outputs can be removed without
+// touching files that were written by hand.
+const manifestFile = ".bindplane-manifest.yaml"
+
+const manifestHeader = "# Managed by the Bindplane action. Files listed here are removed\n# when their configuration no longer exists in Bindplane.\n"
+
+// manifest maps each configuration name to the files, relative
+// to the configuration output directory, rendered for it
+type manifest struct {
+	Configurations map[string][]string `yaml:"configurations"`
+}
+
+// readManifest reads the manifest from dir. An empty manifest is
+// returned when the directory does not contain one.
+func readManifest(dir string) (*manifest, error) {
+	m := &manifest{Configurations: map[string][]string{}}
+
+	b, err := os.ReadFile(filepath.Join(dir, manifestFile)) // #nosec G304 user defined filepath
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("manifest %s is malformed, failed to unmarshal yaml: %w", filepath.Join(dir, manifestFile), err)
+	}
+	if m.Configurations == nil {
+		m.Configurations = map[string][]string{}
+	}
+
+	return m, nil
+}
+
+// write writes the manifest to dir
+func (m *manifest) write(dir string) error {
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+
+	path := filepath.Join(dir, manifestFile)
+	if err := os.WriteFile(path, append([]byte(manifestHeader), b...), 0600); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+
+	return nil
+}
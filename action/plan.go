@@ -0,0 +1,218 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/observiq/bindplane-op-action/internal/client"
+	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"go.uber.org/zap"
+)
+
+// PlannedChange is the change Apply would make to a resource
+type PlannedChange struct {
+	Kind model.Kind
+	Name string
+
+	// Path is the file the resource was read from
+	Path string
+
+	// Status is created, configured or unchanged
+	Status model.UpdateStatus
+}
+
+// resourcePath is a configured resource path and the kind of resource it holds
+type resourcePath struct {
+	kind model.Kind
+	path string
+}
+
+// resourcePaths returns the configured resource paths in the order
+// they are applied. See Apply for why the order is important.
+func (a *Action) resourcePaths() []resourcePath {
+	paths := []resourcePath{
+		{model.KindDestination, a.destinationPath},
+		{model.KindSource, a.sourcePath},
+		{model.KindProcessor, a.processorPath},
+		{model.KindConnector, a.connectorPath},
+		{model.KindConfiguration, a.configurationPath},
+		{model.KindFleet, a.fleetPath},
+	}
+
+	configured := make([]resourcePath, 0, len(paths))
+	for _, p := range paths {
+		if p.path != "" {
+			configured = append(configured, p)
+		}
+	}
+	return configured
+}
+
+// walkResources decodes each resource file of the configured resource
+// paths in apply order and calls fn with its resources. Sensitive values
+// are collected before fn is called.
+func (a *Action) walkResources(fn func(file string, resources []*model.AnyResource) error) error {
+	for _, p := range a.resourcePaths() {
+		files, err := a.resourceFiles(p.path)
+		if err != nil {
+			return fmt.Errorf("%s path %s: %w", p.kind, p.path, err)
+		}
+
+		for _, file := range files {
+			resources, err := decodeAnyResourceFile(file)
+			if err != nil {
+				return &ResourceError{Path: file, Err: classify(ErrInvalidResource, fmt.Errorf("decode resources: %w", err))}
+			}
+
+			a.collectSensitiveValues(resources)
+
+			if err := fn(file, resources); err != nil {
+				return &ResourceError{Path: file, Err: err}
+			}
+		}
+	}
+	return nil
+}
+
+// Load reads the resources of the configured resource paths into the
+// state without applying them. This allows AutoRollout and WriteBack
+// to run for resources which were applied by a previous run.
+func (a *Action) Load() error {
+	return a.walkResources(func(file string, resources []*model.AnyResource) error {
+		for _, r := range resources {
+			if r == nil {
+				continue
+			}
+			a.recordResource(*r)
+			a.Logger.Debug("Loaded resource",
+				zap.String("kind", r.Kind),
+				zap.String("name", r.Metadata.Name),
+				zap.String("resource_path", file),
+			)
+		}
+		return nil
+	})
+}
+
+// Plan compares the resources of the configured resource paths with
+// BindPlane and returns the change Apply would make to each of them.
+// Nothing is applied. Fields which are not set in the resource file,
+// such as those defaulted by BindPlane, are not compared.
+func (a *Action) Plan() ([]PlannedChange, error) {
+	changes := []PlannedChange{}
+	err := a.walkResources(func(file string, resources []*model.AnyResource) error {
+		for _, r := range resources {
+			if r == nil {
+				continue
+			}
+
+			status, err := a.planResource(r)
+			if err != nil {
+				return fmt.Errorf("plan %s %s: %w", r.Kind, r.Metadata.Name, err)
+			}
+
+			a.Logger.Info("Planned resource",
+				zap.String("kind", r.Kind),
+				zap.String("name", r.Metadata.Name),
+				zap.String("status", string(status)),
+				zap.String("resource_path", file),
+			)
+			changes = append(changes, PlannedChange{
+				Kind:   model.Kind(r.Kind),
+				Name:   r.Metadata.Name,
+				Path:   file,
+				Status: status,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// planResource returns the status applying the resource would have
+func (a *Action) planResource(r *model.AnyResource) (model.UpdateStatus, error) {
+	current, err := a.client.Resource(context.Background(), model.Kind(r.Kind), r.Metadata.Name)
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		return model.StatusCreated, nil
+	case err != nil:
+		return "", err
+	}
+
+	desired, err := userFields(r)
+	if err != nil {
+		return "", err
+	}
+	existing, err := userFields(current)
+	if err != nil {
+		return "", err
+	}
+
+	if isSubset(desired, existing) {
+		return model.StatusUnchanged, nil
+	}
+	return model.StatusConfigured, nil
+}
+
+// userFields returns the user defined fields of a resource as generic
+// JSON values, so resources decoded from YAML and JSON can be compared
+func userFields(r *model.AnyResource) (any, error) {
+	b, err := json.Marshal(map[string]any{
+		"displayName": r.Metadata.DisplayName,
+		"description": r.Metadata.Description,
+		"labels":      r.Metadata.Labels,
+		"spec":        r.Spec,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal resource: %w", err)
+	}
+
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("unmarshal resource: %w", err)
+	}
+	return v, nil
+}
+
+// isSubset returns true if every value set in desired is equal in
+// existing. Empty values in desired are treated as unset.
+func isSubset(desired, existing any) bool {
+	switch d := desired.(type) {
+	case nil:
+		return true
+	case string:
+		if d == "" {
+			return true
+		}
+	case map[string]any:
+		e, ok := existing.(map[string]any)
+		if !ok {
+			return len(d) == 0
+		}
+		for k, v := range d {
+			if !isSubset(v, e[k]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		e, ok := existing.([]any)
+		if !ok || len(d) != len(e) {
+			return false
+		}
+		for i := range d {
+			if !isSubset(d[i], e[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(desired, existing)
+}
@@ -0,0 +1,163 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const planDestinations = `---
+apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: gateway
+spec:
+  type: otlp_grpc
+  parameters:
+    - name: hostname
+      value: gateway.example.com
+---
+apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: logging
+spec:
+  type: logging
+---
+apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: new
+spec:
+  type: logging
+`
+
+const planConfigurations = `---
+apiVersion: bindplane.observiq.com/v1
+kind: Configuration
+metadata:
+  name: agents
+  labels:
+    platform: linux
+spec:
+  destinations:
+    - name: gateway
+`
+
+func TestPlan(t *testing.T) {
+	dir := t.TempDir()
+	destinations := filepath.Join(dir, "destinations.yaml")
+	configurations := filepath.Join(dir, "configurations.yaml")
+	require.NoError(t, os.WriteFile(destinations, []byte(planDestinations), 0600))
+	require.NoError(t, os.WriteFile(configurations, []byte(planConfigurations), 0600))
+
+	existing := map[string]string{
+		// Fields set only by BindPlane are ignored
+		"destinations/gateway": `{"destination": {"kind": "Destination", "metadata": {"id": "1", "name": "gateway", "version": 3},
+			"spec": {"type": "otlp_grpc", "parameters": [{"name": "hostname", "value": "gateway.example.com"}]}}}`,
+		"destinations/logging": `{"destination": {"kind": "Destination", "metadata": {"name": "logging"}, "spec": {"type": "custom"}}}`,
+		"configurations/agents": `{"configuration": {"kind": "Configuration", "metadata": {"name": "agents", "labels": {"platform": "windows"}},
+			"spec": {"destinations": [{"name": "gateway"}]}}}`,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/{kind}/{name}", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := existing[r.PathValue("kind")+"/"+r.PathValue("name")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	})
+	mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("plan must not apply resources")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a, err := New(zap.NewNop(),
+		WithBindPlaneRemoteURL(server.URL),
+		WithBindPlaneAPIKey("key"),
+		WithDestinationPath(destinations),
+		WithConfigurationPath(configurations),
+	)
+	require.NoError(t, err)
+
+	changes, err := a.Plan()
+	require.NoError(t, err)
+	require.Equal(t, []PlannedChange{
+		{Kind: model.KindDestination, Name: "gateway", Path: destinations, Status: model.StatusUnchanged},
+		{Kind: model.KindDestination, Name: "logging", Path: destinations, Status: model.StatusConfigured},
+		{Kind: model.KindDestination, Name: "new", Path: destinations, Status: model.StatusCreated},
+		{Kind: model.KindConfiguration, Name: "agents", Path: configurations, Status: model.StatusConfigured},
+	}, changes)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "destinations"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "destinations", "all.yaml"), []byte(planDestinations), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "configurations.yaml"), []byte(planConfigurations), 0600))
+
+	a, err := New(zap.NewNop(),
+		WithBindPlaneRemoteURL("http://localhost:3001"),
+		WithDestinationPath(filepath.Join(dir, "destinations")),
+		WithConfigurationPath(filepath.Join(dir, "*.yaml")),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, a.Load())
+	require.Equal(t, []string{"agents"}, a.state.ConfigurationNames())
+	require.ElementsMatch(t, []string{"gateway", "logging", "new"}, a.state.ResourceNames(model.KindDestination))
+}
+
+func TestLoadMalformed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "destinations.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("kind: [Destination"), 0600))
+
+	a, err := New(zap.NewNop(),
+		WithBindPlaneRemoteURL("http://localhost:3001"),
+		WithDestinationPath(path),
+	)
+	require.NoError(t, err)
+
+	err = a.Load()
+	require.Error(t, err)
+
+	var resourceErr *ResourceError
+	require.ErrorAs(t, err, &resourceErr)
+	require.Equal(t, path, resourceErr.File())
+}
+
+func TestIsSubset(t *testing.T) {
+	cases := []struct {
+		name     string
+		desired  any
+		existing any
+		expect   bool
+	}{
+		{"Equal", map[string]any{"a": "b"}, map[string]any{"a": "b"}, true},
+		{"Extra existing field", map[string]any{"a": "b"}, map[string]any{"a": "b", "c": "d"}, true},
+		{"Different value", map[string]any{"a": "b"}, map[string]any{"a": "c"}, false},
+		{"Missing field", map[string]any{"a": "b"}, map[string]any{}, false},
+		{"Empty string is unset", map[string]any{"a": ""}, map[string]any{}, true},
+		{"List length", []any{"a"}, []any{"a", "b"}, false},
+		{"Nested list", []any{map[string]any{"a": 1.0}}, []any{map[string]any{"a": 1.0, "b": true}}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expect, isSubset(tc.desired, tc.existing))
+		})
+	}
+}
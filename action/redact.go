@@ -0,0 +1,281 @@
+package action
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"github.com/observiq/bindplane-op-action/internal/logging"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRedactKeyPatterns are the key patterns used to find secrets in
+// rendered configurations when none are configured
+var DefaultRedactKeyPatterns = []string{"*api_key*", "*apikey*", "*password*", "*secret*", "*token*"}
+
+// minSensitiveValueLength is the shortest sensitive parameter value which
+// is redacted. Shorter values are too likely to appear in unrelated fields.
+const minSensitiveValueLength = 4
+
+// WithRedactRenderedConfigurations enables replacing secrets in rendered
+// configurations with environment variable references before they are
+// written back
+func WithRedactRenderedConfigurations(enable bool) Option {
+	return func(a *Action) {
+		a.redactRendered = enable
+	}
+}
+
+// WithRedactKeyPatterns sets the glob patterns matched against keys of
+// rendered configurations to find secrets. Matching is case insensitive
+// and hyphens in keys match underscores. Defaults to DefaultRedactKeyPatterns.
+func WithRedactKeyPatterns(patterns []string) Option {
+	return func(a *Action) {
+		a.redactKeyPatterns = patterns
+	}
+}
+
+// ValidateRedactKeyPatterns returns an error if any pattern is malformed
+func ValidateRedactKeyPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// WithMasker sets the masker which the values of parameters marked
+// sensitive are added to, so they are masked in logs and the trace file
+func WithMasker(m *logging.Masker) Option {
+	return func(a *Action) {
+		a.masker = m
+		a.config.Trace.Scrub = m.Scrub
+	}
+}
+
+// collectSensitiveValues records the values of parameters marked sensitive
+// in the resources. Each value is redacted to an environment variable named
+// after the resource and parameter, such as OTLP_GATEWAY_API_KEY, and
+// masked in logs.
+func (a *Action) collectSensitiveValues(resources []*model.AnyResource) {
+	for _, r := range resources {
+		if r == nil {
+			continue
+		}
+		collectSensitive(r.Spec, func(param, value string) {
+			a.state.AddSensitiveValue(envVarName(r.Metadata.Name, param), value)
+			a.masker.Add(value)
+		})
+	}
+}
+
+// collectSensitive calls add with the name and each string value of
+// every parameter marked sensitive within v
+func collectSensitive(v any, add func(param, value string)) {
+	switch t := v.(type) {
+	case map[string]any:
+		if sensitive, ok := t["sensitive"].(bool); ok && sensitive {
+			if name, ok := t["name"].(string); ok {
+				for _, value := range stringValues(t["value"]) {
+					add(name, value)
+				}
+			}
+		}
+		for _, child := range t {
+			collectSensitive(child, add)
+		}
+	case []any:
+		for _, child := range t {
+			collectSensitive(child, add)
+		}
+	}
+}
+
+// stringValues returns the strings within a parameter value
+func stringValues(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var values []string
+		for _, child := range t {
+			values = append(values, stringValues(child)...)
+		}
+		return values
+	case map[string]any:
+		var values []string
+		for _, child := range t {
+			values = append(values, stringValues(child)...)
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// redactRawConfigurations redacts secrets from each rendered configuration
+func (a *Action) redactRawConfigurations(rawConfigs map[string]string) error {
+	patterns := a.redactKeyPatterns
+	if len(patterns) == 0 {
+		patterns = DefaultRedactKeyPatterns
+	}
+	sensitive := a.state.SensitiveValues()
+
+	for name, rawConfig := range rawConfigs {
+		redacted, envVars, err := redactConfiguration(rawConfig, sensitive, patterns)
+		if err != nil {
+			return fmt.Errorf("redact configuration %s: %w", name, err)
+		}
+		if len(envVars) == 0 {
+			continue
+		}
+
+		rawConfigs[name] = redacted
+		a.Logger.Info(
+			"Redacted secrets from rendered configuration, agents must set the environment variables",
+			zap.String("name", name),
+			zap.Strings("env", envVars),
+		)
+	}
+
+	return nil
+}
+
+// redactConfiguration replaces secrets in the raw configuration with
+// ${env:NAME} references. Occurrences of sensitive values are replaced
+// with their environment variable, and string values of keys matching a
+// pattern are replaced with a variable named after their path, such as
+// OTLP_GATEWAY_HEADERS_X_API_KEY for exporters.otlp/gateway.headers.x-api-key.
+// The sorted names of referenced environment variables are returned, and
+// the raw configuration is returned unchanged when there are none.
+func redactConfiguration(raw string, sensitive map[string]string, patterns []string) (string, []string, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(raw), doc); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal yaml: %w", err)
+	}
+
+	// Replace longer values first so values containing
+	// another sensitive value are replaced whole
+	names := make([]string, 0, len(sensitive))
+	for name, value := range sensitive {
+		if len(value) >= minSensitiveValueLength {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		vi, vj := sensitive[names[i]], sensitive[names[j]]
+		if len(vi) != len(vj) {
+			return len(vi) > len(vj)
+		}
+		return names[i] < names[j]
+	})
+
+	envVars := map[string]struct{}{}
+	var walk func(node *yaml.Node, keys []string)
+	walk = func(node *yaml.Node, keys []string) {
+		switch node.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, child := range node.Content {
+				walk(child, keys)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], append(keys, node.Content[i].Value))
+			}
+		case yaml.ScalarNode:
+			// Booleans and nulls are settings rather than secrets, even
+			// under keys such as secret_enabled
+			if node.Value == "" || node.Tag == "!!bool" || node.Tag == "!!null" {
+				return
+			}
+
+			if node.Tag == "!!str" {
+				for _, name := range names {
+					ref := envReference(name)
+					if strings.Contains(node.Value, sensitive[name]) {
+						node.Value = strings.ReplaceAll(node.Value, sensitive[name], ref)
+						envVars[name] = struct{}{}
+					}
+				}
+			}
+
+			if len(keys) == 0 || strings.Contains(node.Value, "${") {
+				return
+			}
+			if matchesAny(keys[len(keys)-1], patterns) {
+				// The top level section, such as exporters, is left out of the name
+				name := envVarName(keys[min(1, len(keys)-1):]...)
+				node.Value = envReference(name)
+				node.Tag = "!!str"
+				envVars[name] = struct{}{}
+			}
+		}
+	}
+	walk(doc, nil)
+
+	if len(envVars) == 0 {
+		return raw, nil, nil
+	}
+
+	b, err := marshalYAML(doc)
+	if err != nil {
+		return "", nil, fmt.Errorf("marshal yaml: %w", err)
+	}
+
+	sorted := make([]string, 0, len(envVars))
+	for name := range envVars {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	return string(b), sorted, nil
+}
+
+// matchesAny returns true if the key matches any of the patterns,
+// ignoring case. Hyphens in the key are matched as underscores, so
+// *api_key* matches header names such as x-api-key.
+func matchesAny(key string, patterns []string) bool {
+	key = strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// envReference returns the collector environment variable reference for name
+func envReference(name string) string {
+	return fmt.Sprintf("${env:%s}", name)
+}
+
+// envVarName returns an environment variable name built from the parts,
+// upper cased with other characters replaced by underscores
+func envVarName(parts ...string) string {
+	b := strings.Builder{}
+	underscore := false
+	for _, part := range parts {
+		for _, r := range strings.ToUpper(part) {
+			if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				if underscore && b.Len() > 0 {
+					b.WriteByte('_')
+				}
+				underscore = false
+				b.WriteRune(r)
+				continue
+			}
+			underscore = true
+		}
+		underscore = true
+	}
+
+	name := b.String()
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
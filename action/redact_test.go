@@ -0,0 +1,141 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"github.com/observiq/bindplane-op-action/internal/logging"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestEnvVarName(t *testing.T) {
+	cases := []struct {
+		parts    []string
+		expected string
+	}{
+		{[]string{"otlp-gateway", "api_key"}, "OTLP_GATEWAY_API_KEY"},
+		{[]string{"otlp/gateway", "headers", "x-api-key"}, "OTLP_GATEWAY_HEADERS_X_API_KEY"},
+		{[]string{"--splunk--", "token"}, "SPLUNK_TOKEN"},
+		{[]string{"3scale", "secret"}, "_3SCALE_SECRET"},
+		{[]string{"???"}, "_"},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.expected, envVarName(tc.parts...))
+	}
+}
+
+func TestRedactConfiguration(t *testing.T) {
+	sensitive := map[string]string{
+		"OTLP_GATEWAY_API_KEY": "abcd1234",
+		"SHORT_PASSWORD":       "abc",
+	}
+
+	cases := []struct {
+		name     string
+		raw      string
+		expected string
+		envVars  []string
+	}{
+		{
+			name:     "unchanged",
+			raw:      "receivers:\n    otlp: {}\n",
+			expected: "receivers:\n    otlp: {}\n",
+		},
+		{
+			name:     "sensitive-value",
+			raw:      "exporters:\n  otlp/gateway:\n    headers:\n      authorization: Bearer abcd1234\n",
+			expected: "exporters:\n  otlp/gateway:\n    headers:\n      authorization: Bearer ${env:OTLP_GATEWAY_API_KEY}\n",
+			envVars:  []string{"OTLP_GATEWAY_API_KEY"},
+		},
+		{
+			name:     "key-pattern",
+			raw:      "exporters:\n  otlp/gateway:\n    headers:\n      x-api-key: plain\n    password: hunter2\n    timeout: 5s\n",
+			expected: "exporters:\n  otlp/gateway:\n    headers:\n      x-api-key: ${env:OTLP_GATEWAY_HEADERS_X_API_KEY}\n    password: ${env:OTLP_GATEWAY_PASSWORD}\n    timeout: 5s\n",
+			envVars:  []string{"OTLP_GATEWAY_HEADERS_X_API_KEY", "OTLP_GATEWAY_PASSWORD"},
+		},
+		{
+			name:     "sensitive-value-under-pattern-key",
+			raw:      "exporters:\n  otlp:\n    api_key: abcd1234\n",
+			expected: "exporters:\n  otlp:\n    api_key: ${env:OTLP_GATEWAY_API_KEY}\n",
+			envVars:  []string{"OTLP_GATEWAY_API_KEY"},
+		},
+		{
+			name:     "key-pattern-numeric",
+			raw:      "exporters:\n  otlp:\n    password: 123456\n    api_key: 0042\n    timeout: 10\n",
+			expected: "exporters:\n  otlp:\n    password: ${env:OTLP_PASSWORD}\n    api_key: ${env:OTLP_API_KEY}\n    timeout: 10\n",
+			envVars:  []string{"OTLP_API_KEY", "OTLP_PASSWORD"},
+		},
+		{
+			name:     "skipped",
+			raw:      "exporters:\n  otlp:\n    api_key: ${env:EXISTING}\n    password: \"\"\n    token: null\n    secret_enabled: true\n    note: abc\n",
+			expected: "exporters:\n  otlp:\n    api_key: ${env:EXISTING}\n    password: \"\"\n    token: null\n    secret_enabled: true\n    note: abc\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			redacted, envVars, err := redactConfiguration(tc.raw, sensitive, DefaultRedactKeyPatterns)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, redacted)
+			require.Equal(t, tc.envVars, envVars)
+		})
+	}
+
+	_, _, err := redactConfiguration("receivers: [", sensitive, DefaultRedactKeyPatterns)
+	require.ErrorContains(t, err, "failed to unmarshal yaml")
+}
+
+func TestCollectSensitiveValues(t *testing.T) {
+	masker := logging.NewMasker(nil)
+	a, err := New(zap.NewNop(), WithBindPlaneRemoteURL("http://localhost:3001"), WithMasker(masker))
+	require.NoError(t, err)
+
+	destination := &model.AnyResource{
+		Spec: map[string]any{
+			"type": "otlp",
+			"parameters": []any{
+				map[string]any{"name": "api_key", "value": "abcd1234", "sensitive": true},
+				map[string]any{"name": "headers", "value": map[string]any{"x-token": "efgh5678"}, "sensitive": true},
+				map[string]any{"name": "endpoint", "value": "gateway:4317"},
+			},
+		},
+	}
+	destination.Kind = string(model.KindDestination)
+	destination.Metadata.Name = "otlp-gateway"
+
+	a.collectSensitiveValues([]*model.AnyResource{destination, nil})
+	require.Equal(t, map[string]string{
+		"OTLP_GATEWAY_API_KEY": "abcd1234",
+		"OTLP_GATEWAY_HEADERS": "efgh5678",
+	}, a.state.SensitiveValues())
+	require.Equal(t, "key *** endpoint gateway:4317", masker.Scrub("key abcd1234 endpoint gateway:4317"))
+}
+
+func TestValidateRedactKeyPatterns(t *testing.T) {
+	require.NoError(t, ValidateRedactKeyPatterns(DefaultRedactKeyPatterns))
+	require.ErrorContains(t, ValidateRedactKeyPatterns([]string{"[api"}), `invalid pattern "[api"`)
+}
+
+func TestWriteBackRedact(t *testing.T) {
+	remote := newTestRemote(t, "main", nil)
+	server := newTestBindPlane(t, map[string]string{
+		"gateway": "exporters:\n  otlp:\n    headers:\n      authorization: abcd1234\n",
+	})
+
+	a := newTestAction(t, server, []string{"gateway"},
+		WithOTELConfigWriteBack(true),
+		WithConfigurationOutputDir("otel"),
+		WithConfigurationOutputBranch("main"),
+		WithGithubURL(remote),
+		WithRedactRenderedConfigurations(true),
+	)
+	a.state.AddSensitiveValue("OTLP_GATEWAY_API_KEY", "abcd1234")
+
+	require.NoError(t, a.WriteBack())
+	require.Equal(t,
+		"exporters:\n  otlp:\n    headers:\n      authorization: ${env:OTLP_GATEWAY_API_KEY}\n",
+		remoteFiles(t, remote, "main")["otel/gateway.yaml"],
+	)
+}
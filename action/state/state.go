@@ -14,6 +14,20 @@ type State interface {
 
 	// SetConfiguration inserts a configuration into the state
 	SetConfiguration(name string, configuration model.AnyResource)
+
+	// AddResource records a resource of the given kind that was applied
+	AddResource(kind model.Kind, name string)
+
+	// ResourceNames returns the names of all applied resources of the given kind
+	ResourceNames(kind model.Kind) []string
+
+	// AddSensitiveValue records the value of a sensitive parameter
+	// under the environment variable name it is redacted to
+	AddSensitiveValue(name, value string)
+
+	// SensitiveValues returns sensitive parameter values keyed
+	// by environment variable name
+	SensitiveValues() map[string]string
 }
 
 // Memory is a state that stores data in memory
@@ -24,6 +38,14 @@ type Memory struct {
 	// The key is the name of the configuration
 	// and value is the AnyResource representation
 	configurations map[string]model.AnyResource
+
+	// resources is a set of applied resource
+	// names for each resource kind
+	resources map[model.Kind]map[string]struct{}
+
+	// sensitive maps environment variable names
+	// to sensitive parameter values
+	sensitive map[string]string
 }
 
 var _ State = &Memory{}
@@ -32,6 +54,8 @@ var _ State = &Memory{}
 func NewMemory() *Memory {
 	return &Memory{
 		configurations: make(map[string]model.AnyResource),
+		resources:      make(map[model.Kind]map[string]struct{}),
+		sensitive:      make(map[string]string),
 	}
 }
 
@@ -54,3 +78,48 @@ func (m *Memory) SetConfiguration(name string, configuration model.AnyResource)
 	defer m.mu.Unlock()
 	m.configurations[name] = configuration
 }
+
+// AddResource records a resource of the given kind that was applied
+func (m *Memory) AddResource(kind model.Kind, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.resources[kind]; !ok {
+		m.resources[kind] = make(map[string]struct{})
+	}
+	m.resources[kind][name] = struct{}{}
+}
+
+// ResourceNames returns the names of all applied resources of the given kind
+func (m *Memory) ResourceNames(kind model.Kind) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.resources[kind]))
+	for name := range m.resources[kind] {
+		names = append(names, name)
+	}
+	return names
+}
+
+// AddSensitiveValue records the value of a sensitive parameter
+// under the environment variable name it is redacted to
+func (m *Memory) AddSensitiveValue(name, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sensitive[name] = value
+}
+
+// SensitiveValues returns sensitive parameter values keyed
+// by environment variable name
+func (m *Memory) SensitiveValues() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	values := make(map[string]string, len(m.sensitive))
+	for name, value := range m.sensitive {
+		values[name] = value
+	}
+	return values
+}
@@ -26,3 +26,32 @@ func TestNewMemory(t *testing.T) {
 	require.Len(t, out, 1)
 	require.Equal(t, "test", out[0])
 }
+
+func TestMemoryResources(t *testing.T) {
+	memory := NewMemory()
+	require.Empty(t, memory.ResourceNames(model.KindDestination))
+
+	memory.AddResource(model.KindDestination, "otlp")
+	memory.AddResource(model.KindDestination, "otlp")
+	memory.AddResource(model.KindSource, "journald")
+
+	require.Equal(t, []string{"otlp"}, memory.ResourceNames(model.KindDestination))
+	require.Equal(t, []string{"journald"}, memory.ResourceNames(model.KindSource))
+	require.Empty(t, memory.ResourceNames(model.KindProcessor))
+}
+
+func TestMemorySensitiveValues(t *testing.T) {
+	memory := NewMemory()
+	require.Empty(t, memory.SensitiveValues())
+
+	memory.AddSensitiveValue("OTLP_API_KEY", "secret")
+	memory.AddSensitiveValue("OTLP_API_KEY", "rotated")
+	memory.AddSensitiveValue("SPLUNK_TOKEN", "token")
+
+	values := memory.SensitiveValues()
+	require.Equal(t, map[string]string{"OTLP_API_KEY": "rotated", "SPLUNK_TOKEN": "token"}, values)
+
+	// The returned map is a copy
+	values["OTLP_API_KEY"] = "changed"
+	require.Equal(t, "rotated", memory.SensitiveValues()["OTLP_API_KEY"])
+}
@@ -0,0 +1,161 @@
+package action
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Target is a named BindPlane endpoint, usually a project, that resources
+// are applied to. Empty fields fall back to the options the action was
+// configured with, which allows targets to share a remote URL or resource
+// paths while using their own credentials.
+type Target struct {
+	Name string `yaml:"name"`
+
+	// Client options
+	RemoteURL string `yaml:"remote_url"`
+	APIKey    string `yaml:"api_key"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+
+	OIDCTokenURL string `yaml:"oidc_token_url"`
+	OIDCAudience string `yaml:"oidc_audience"`
+
+	// Resource paths
+	DestinationPath   string `yaml:"destination_path"`
+	SourcePath        string `yaml:"source_path"`
+	ProcessorPath     string `yaml:"processor_path"`
+	ConnectorPath     string `yaml:"connector_path"`
+	FleetPath         string `yaml:"fleet_path"`
+	ConfigurationPath string `yaml:"configuration_path"`
+
+	// Write back options
+	ConfigurationOutputDir string `yaml:"configuration_output_dir"`
+}
+
+// targetsFile is the format of the file read by LoadTargets
+type targetsFile struct {
+	Targets []Target `yaml:"targets"`
+}
+
+// envReferencePattern matches environment variable references such as ${API_KEY}
+var envReferencePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LoadTargets reads the targets file at path. Environment variable references
+// such as ${BINDPLANE_PROD_API_KEY} in the remote URL and credential fields are
+// expanded so credentials can be passed in as secrets instead of being
+// committed. Other uses of $ are kept, and referencing an unset variable is
+// an error.
+func LoadTargets(path string) ([]Target, error) {
+	b, err := os.ReadFile(path) // #nosec G304 user defined filepath
+	if err != nil {
+		return nil, fmt.Errorf("read targets file %s: %w", path, err)
+	}
+
+	f := targetsFile{}
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("targets file %s is malformed, failed to unmarshal yaml: %w", path, err)
+	}
+
+	if len(f.Targets) == 0 {
+		return nil, fmt.Errorf("no targets found in file: %s", path)
+	}
+
+	names := make(map[string]struct{}, len(f.Targets))
+	for i, t := range f.Targets {
+		if t.Name == "" {
+			return nil, fmt.Errorf("target %d in %s is missing a name", i, path)
+		}
+		if _, ok := names[t.Name]; ok {
+			return nil, fmt.Errorf("target %s is defined more than once in %s", t.Name, path)
+		}
+		names[t.Name] = struct{}{}
+
+		if err := f.Targets[i].expandEnv(); err != nil {
+			return nil, fmt.Errorf("target %s in %s: %w", t.Name, path, err)
+		}
+	}
+
+	return f.Targets, nil
+}
+
+// expandEnv expands environment variable references in the
+// remote URL and credential fields of the target
+func (t *Target) expandEnv() error {
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"remote_url", &t.RemoteURL},
+		{"api_key", &t.APIKey},
+		{"username", &t.Username},
+		{"password", &t.Password},
+		{"oidc_token_url", &t.OIDCTokenURL},
+		{"oidc_audience", &t.OIDCAudience},
+	}
+
+	for _, f := range fields {
+		var err error
+		*f.value = envReferencePattern.ReplaceAllStringFunc(*f.value, func(ref string) string {
+			name := envReferencePattern.FindStringSubmatch(ref)[1]
+			v, ok := os.LookupEnv(name)
+			if !ok && err == nil {
+				err = fmt.Errorf("%s references unset environment variable %s", f.name, name)
+			}
+			return v
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Options returns the options which configure an Action for the target.
+// Options are only returned for fields that are set, so they should be
+// passed to New after the base options.
+func (t Target) Options() []Option {
+	opts := []Option{}
+
+	// A target which sets its own credentials should not inherit
+	// any credentials from the base options.
+	if t.hasCredentials() {
+		opts = append(opts,
+			WithBindPlaneAPIKey(""),
+			WithBindPlaneUsername(""),
+			WithBindPlanePassword(""),
+			WithBindPlaneOIDCTokenURL(""),
+			WithBindPlaneOIDCAudience(""),
+		)
+	}
+
+	set := func(v string, opt func(string) Option) {
+		if v != "" {
+			opts = append(opts, opt(v))
+		}
+	}
+
+	set(t.RemoteURL, WithBindPlaneRemoteURL)
+	set(t.APIKey, WithBindPlaneAPIKey)
+	set(t.Username, WithBindPlaneUsername)
+	set(t.Password, WithBindPlanePassword)
+	set(t.OIDCTokenURL, WithBindPlaneOIDCTokenURL)
+	set(t.OIDCAudience, WithBindPlaneOIDCAudience)
+	set(t.DestinationPath, WithDestinationPath)
+	set(t.SourcePath, WithSourcePath)
+	set(t.ProcessorPath, WithProcessorPath)
+	set(t.ConnectorPath, WithConnectorPath)
+	set(t.FleetPath, WithFleetPath)
+	set(t.ConfigurationPath, WithConfigurationPath)
+	set(t.ConfigurationOutputDir, WithConfigurationOutputDir)
+
+	return opts
+}
+
+// hasCredentials returns true if the target sets its own credentials
+func (t Target) hasCredentials() bool {
+	return t.APIKey != "" || t.Username != "" || t.OIDCTokenURL != ""
+}
@@ -0,0 +1,122 @@
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTargetsFile(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "targets.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestLoadTargets(t *testing.T) {
+	t.Setenv("TEST_PROD_API_KEY", "prod-key")
+
+	path := writeTargetsFile(t, `
+targets:
+  - name: staging
+    remote_url: https://staging.bindplane.corp.net
+    username: admin
+    password: pa$word${TEST_PROD_API_KEY}
+  - name: production
+    api_key: ${TEST_PROD_API_KEY}
+    configuration_path: configs/production
+`)
+
+	targets, err := LoadTargets(path)
+	require.NoError(t, err)
+	require.Equal(t, []Target{
+		{
+			Name:      "staging",
+			RemoteURL: "https://staging.bindplane.corp.net",
+			Username:  "admin",
+			Password:  "pa$wordprod-key",
+		},
+		{
+			Name:              "production",
+			APIKey:            "prod-key",
+			ConfigurationPath: "configs/production",
+		},
+	}, targets)
+}
+
+func TestLoadTargetsErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		contents string
+		errStr   string
+	}{
+		{
+			"No targets",
+			"targets: []",
+			"no targets found",
+		},
+		{
+			"Missing name",
+			"targets:\n  - remote_url: https://bindplane.corp.net",
+			"missing a name",
+		},
+		{
+			"Duplicate name",
+			"targets:\n  - name: prod\n  - name: prod",
+			"defined more than once",
+		},
+		{
+			"Malformed",
+			"targets: {",
+			"malformed",
+		},
+		{
+			"Unset environment variable",
+			"targets:\n  - name: prod\n    api_key: ${TEST_UNSET_API_KEY}",
+			"api_key references unset environment variable TEST_UNSET_API_KEY",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadTargets(writeTargetsFile(t, tc.contents))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.errStr)
+		})
+	}
+}
+
+func TestTargetOptions(t *testing.T) {
+	base := []Option{
+		WithBindPlaneRemoteURL("https://bindplane.corp.net"),
+		WithBindPlaneUsername("admin"),
+		WithBindPlanePassword("admin"),
+		WithConfigurationPath("configs"),
+	}
+
+	target := Target{
+		Name:                   "production",
+		APIKey:                 "prod-key",
+		ConfigurationOutputDir: "otel/production",
+	}
+
+	a := &Action{}
+	for _, opt := range append(base, target.Options()...) {
+		opt(a)
+	}
+
+	require.Equal(t, &Action{
+		config: config.Config{
+			Auth: config.Auth{
+				APIKey: "prod-key",
+			},
+			Network: config.Network{
+				RemoteURL: "https://bindplane.corp.net",
+			},
+		},
+		configurationPath:      "configs",
+		configurationOutputDir: "otel/production",
+	}, a)
+}
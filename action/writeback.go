@@ -0,0 +1,772 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/observiq/bindplane-op-action/internal/client"
+	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"github.com/observiq/bindplane-op-action/internal/github"
+	"github.com/observiq/bindplane-op-action/internal/otelconfig"
+	"github.com/observiq/bindplane-op-action/internal/repo"
+	"go.uber.org/zap"
+)
+
+const (
+	// WriteBackModePush commits rendered configurations
+	// directly to the configuration output branch.
+	WriteBackModePush = "push"
+
+	// WriteBackModePullRequest pushes rendered configurations to a
+	// generated branch and opens a pull request against the
+	// configuration output branch.
+	WriteBackModePullRequest = "pull_request"
+
+	// pullRequestBranchPrefix is prepended to the configuration output
+	// branch to generate the branch used for write back pull requests.
+	// See pullRequestBranch.
+	pullRequestBranchPrefix = "bindplane-op-action/"
+
+	// DefaultPushRetries is the number of times the action
+	// retries a rejected write back push by default
+	DefaultPushRetries = 3
+
+	// defaultPushRetryBackoff is the delay before the first push retry
+	defaultPushRetryBackoff = 2 * time.Second
+)
+
+// WithWriteBackMode sets how rendered configurations are written back,
+// either WriteBackModePush or WriteBackModePullRequest
+func WithWriteBackMode(m string) Option {
+	return func(a *Action) {
+		a.writeBackMode = m
+	}
+}
+
+// WithGithubAPIURL sets the GitHub API URL used to open write back pull requests
+func WithGithubAPIURL(u string) Option {
+	return func(a *Action) {
+		a.githubAPIURL = u
+	}
+}
+
+// WithWriteBackAllConfigurations enables rendering every configuration
+// on the server during write back, instead of only the configurations
+// affected by this run
+func WithWriteBackAllConfigurations(enable bool) Option {
+	return func(a *Action) {
+		a.writeBackAll = enable
+	}
+}
+
+// WithValidateRenderedConfigurations enables checking the structure of
+// rendered configurations before they are written back
+func WithValidateRenderedConfigurations(enable bool) Option {
+	return func(a *Action) {
+		a.validateRendered = enable
+	}
+}
+
+// WithPushRetries sets the number of times a rejected write back push is
+// retried on top of the updated configuration output branch
+func WithPushRetries(n int) Option {
+	return func(a *Action) {
+		a.pushRetries = n
+	}
+}
+
+// WriteBack renders the configurations affected by this run and
+// commits them to the configuration output branch, either directly or
+// by opening a pull request. Errors are classified as ErrWriteBack.
+func (a *Action) WriteBack() error {
+	return classify(ErrWriteBack, a.writeBack())
+}
+
+func (a *Action) writeBack() error {
+	names, err := a.writeBackConfigurationNames()
+	if err != nil {
+		return err
+	}
+
+	rawConfigs := make(map[string]string)
+	for _, name := range names {
+		rawConfig, err := a.client.RawConfiguration(context.Background(), name)
+		if err != nil {
+			return fmt.Errorf("get configuration %s: %w", name, err)
+		}
+		if rawConfig == "" {
+			return fmt.Errorf("configuration '%s' is empty: %s", name, BugError)
+		}
+		rawConfigs[name] = rawConfig
+	}
+
+	if a.redactRendered {
+		if err := a.redactRawConfigurations(rawConfigs); err != nil {
+			return err
+		}
+	}
+
+	// Nothing is committed when any configuration is invalid
+	if a.validateRendered {
+		if err := a.validateRawConfigurations(rawConfigs); err != nil {
+			return err
+		}
+	}
+
+	cloneURL, token, err := a.writeBackRepository()
+	if err != nil {
+		return err
+	}
+
+	proxyOpts, err := repo.ProxyOptions(cloneURL, a.config.Network.Proxy)
+	if err != nil {
+		return fmt.Errorf("proxy options: %w", err)
+	}
+
+	r, tree, err := a.openWriteBackRepository(cloneURL, token)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			a.Logger.Warn("Failed to clean up repository", zap.Error(err))
+		}
+	}()
+
+	// Pull request branches build on the open pull request, so changes
+	// from earlier runs which were not merged yet are kept. Without an open
+	// pull request, the branch is recreated from the output branch and
+	// force pushed to replace a branch left by a merged or closed one.
+	pushBranch := a.configurationOutputBranch
+	force := false
+	var pr *github.PullRequest
+	if a.writeBackMode == WriteBackModePullRequest {
+		pushBranch = a.pullRequestBranch()
+		pr, err = a.findPullRequest(cloneURL, token, pushBranch)
+		if err != nil {
+			return fmt.Errorf("pull request: %w", err)
+		}
+
+		force, err = a.checkoutPullRequestBranch(r.Repository, tree, cloneURL, pushBranch, pr != nil, proxyOpts)
+		if err != nil {
+			return err
+		}
+	}
+
+	var (
+		changed []string
+		staged  bool
+		message string
+	)
+	for attempt := 0; ; attempt++ {
+		changed, staged, err = a.writeConfigurations(tree, rawConfigs)
+		if err != nil {
+			return err
+		}
+
+		// The manifest may change without any configuration
+		// changing, such as when it is first written
+		if !staged {
+			a.Logger.Info("No changes to write back")
+			return nil
+		}
+
+		a.Logger.Info("Detected changes, writing back to repository")
+		message, err = a.commit(tree, pushBranch, changed)
+		if err != nil {
+			return err
+		}
+
+		err = a.push(r.Repository, cloneURL, pushBranch, force, proxyOpts)
+		if err == nil {
+			break
+		}
+
+		if !isPushRejected(err) {
+			return fmt.Errorf("push changes: %w", err)
+		}
+		if attempt >= a.pushRetries {
+			return classify(ErrConflict, fmt.Errorf("push changes: %w", err))
+		}
+
+		// Another run updated the branch since it was cloned. Rebuild
+		// the commit on top of the new head and try again.
+		backoff := a.pushRetryBackoff
+		if backoff == 0 {
+			backoff = defaultPushRetryBackoff
+		}
+		backoff *= time.Duration(1 << attempt)
+		a.Logger.Warn(
+			"Push rejected, retrying on top of the updated branch",
+			zap.Error(err),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", backoff),
+		)
+		time.Sleep(backoff)
+
+		if err := a.resetToRemote(r.Repository, tree, cloneURL, pushBranch, proxyOpts); err != nil {
+			return err
+		}
+	}
+
+	if a.writeBackMode == WriteBackModePullRequest {
+		if err := a.openPullRequest(cloneURL, token, pushBranch, message, changed, pr); err != nil {
+			return fmt.Errorf("pull request: %w", err)
+		}
+	}
+
+	a.Logger.Info("Changes written back to repository")
+
+	return nil
+}
+
+// validateRawConfigurations checks the structure of each rendered
+// configuration, returning the problems found for every configuration
+func (a *Action) validateRawConfigurations(rawConfigs map[string]string) error {
+	names := make([]string, 0, len(rawConfigs))
+	for name := range rawConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if err := otelconfig.Validate(rawConfigs[name]); err != nil {
+			a.Logger.Error("Rendered configuration is invalid", zap.String("name", name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("configuration %s: %w", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("rendered configurations are invalid: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// openWriteBackRepository returns the repository and worktree which
+// configurations are written to. When the workspace checkout has the
+// configuration output branch checked out, it is cloned locally instead
+// of over the network. The workspace itself is never modified, and its
+// uncommitted changes are not included. Otherwise, the configuration
+// output branch is cloned from the remote.
+func (a *Action) openWriteBackRepository(cloneURL, token string) (*repo.Repository, *git.Worktree, error) {
+	opts := repo.Options{
+		URL:     cloneURL,
+		Branch:  a.configurationOutputBranch,
+		Token:   token,
+		Proxy:   a.config.Network.Proxy,
+		Depth:   a.cloneDepth,
+		Timeout: a.cloneTimeout,
+	}
+
+	var r *repo.Repository
+	if a.workspace != "" {
+		var err error
+		r, err = repo.CloneWorkspace(a.workspace, cloneURL, a.configurationOutputBranch)
+		if err == nil {
+			a.Logger.Info("Cloned workspace checkout", zap.String("branch", a.configurationOutputBranch))
+		} else {
+			a.Logger.Debug("Not using workspace checkout", zap.Error(err))
+		}
+	}
+
+	if r == nil {
+		a.Logger.Info(
+			"Cloning repository", zap.String("branch", a.configurationOutputBranch),
+		)
+
+		var err error
+		r, err = repo.Clone(opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("clone repository: %w", err)
+		}
+	}
+
+	tree, err := r.Worktree()
+	if err != nil {
+		_ = r.Close()
+		return nil, nil, fmt.Errorf("get worktree: %w", err)
+	}
+
+	return r, tree, nil
+}
+
+// commit commits the staged changes and returns the commit message
+func (a *Action) commit(tree *git.Worktree, branch string, changed []string) (string, error) {
+	commitOptions, err := a.commitOptions()
+	if err != nil {
+		return "", err
+	}
+
+	message, err := a.commitMessage(branch, changed)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tree.Commit(message, commitOptions); err != nil {
+		return "", fmt.Errorf("commit changes: %w", err)
+	}
+
+	return message, nil
+}
+
+// push pushes branch to the origin remote at cloneURL. Force replaces
+// the remote branch, which is used for recreated pull request branches.
+func (a *Action) push(r *git.Repository, cloneURL, branch string, force bool, proxyOpts transport.ProxyOptions) error {
+	refSpec := fmt.Sprintf("refs/heads/%s:refs/heads/%s", branch, branch)
+	pushOpts := &git.PushOptions{
+		RemoteName:   "origin",
+		RemoteURL:    cloneURL,
+		RefSpecs:     []gitconfig.RefSpec{gitconfig.RefSpec(refSpec)},
+		Force:        force,
+		ProxyOptions: proxyOpts,
+	}
+
+	// The pull request branch is already up to date
+	// when a previous run pushed the same changes.
+	if err := r.Push(pushOpts); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+	return nil
+}
+
+// isPushRejected returns true if the push was rejected because the
+// remote branch contains commits which are not in the local branch
+func isPushRejected(err error) bool {
+	if errors.Is(err, git.ErrNonFastForwardUpdate) {
+		return true
+	}
+
+	// Rejections reported by the remote are only available as text
+	msg := err.Error()
+	return strings.Contains(msg, "non-fast-forward") || strings.Contains(msg, "fetch first")
+}
+
+// resetToRemote fetches branch and hard resets the
+// worktree to it, discarding the local write back commit
+func (a *Action) resetToRemote(r *git.Repository, tree *git.Worktree, cloneURL, branch string, proxyOpts transport.ProxyOptions) error {
+	hash, err := fetchBranch(r, cloneURL, branch, proxyOpts)
+	if err != nil {
+		return err
+	}
+
+	if err := tree.Reset(&git.ResetOptions{Commit: hash, Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("reset to branch %s: %w", branch, err)
+	}
+
+	return nil
+}
+
+// fetchBranch fetches branch from the origin remote at
+// cloneURL and returns the hash of its head commit
+func fetchBranch(r *git.Repository, cloneURL, branch string, proxyOpts transport.ProxyOptions) (plumbing.Hash, error) {
+	remoteRef := plumbing.NewRemoteReferenceName("origin", branch)
+	refSpec := fmt.Sprintf("+refs/heads/%s:%s", branch, remoteRef)
+
+	err := r.Fetch(&git.FetchOptions{
+		RemoteName:   "origin",
+		RemoteURL:    cloneURL,
+		RefSpecs:     []gitconfig.RefSpec{gitconfig.RefSpec(refSpec)},
+		Force:        true,
+		ProxyOptions: proxyOpts,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return plumbing.ZeroHash, fmt.Errorf("fetch branch %s: %w", branch, err)
+	}
+
+	ref, err := r.Reference(remoteRef, true)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("resolve branch %s: %w", branch, err)
+	}
+	return ref.Hash(), nil
+}
+
+// pullRequestBranch returns the branch write back pull requests are
+// opened from. The configuration output directory is part of the name,
+// so targets writing to different directories use their own branches.
+func (a *Action) pullRequestBranch() string {
+	branch := pullRequestBranchPrefix + a.configurationOutputBranch
+	if dir := branchNameSlug(a.configurationOutputDir); dir != "" {
+		branch += "-" + dir
+	}
+	return branch
+}
+
+// branchNameSlug returns s with characters which are not letters,
+// digits, dots or underscores replaced by hyphens
+func branchNameSlug(s string) string {
+	b := strings.Builder{}
+	hyphen := false
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '_':
+			b.WriteRune(c)
+			hyphen = false
+		case !hyphen:
+			b.WriteRune('-')
+			hyphen = true
+		}
+	}
+	return strings.Trim(b.String(), "-.")
+}
+
+// checkoutPullRequestBranch checks out the pull request branch. When
+// the pull request is open, the branch is created from the remote
+// branch. Otherwise, it is created from the output branch, and true is
+// returned because it must be force pushed to replace the remote branch.
+func (a *Action) checkoutPullRequestBranch(r *git.Repository, tree *git.Worktree, cloneURL, branch string, open bool, proxyOpts transport.ProxyOptions) (bool, error) {
+	opts := &git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(branch),
+		Create: true,
+	}
+
+	force := true
+	if open {
+		hash, err := fetchBranch(r, cloneURL, branch, proxyOpts)
+		switch {
+		case err == nil:
+			opts.Hash = hash
+			force = false
+		case errors.Is(err, git.NoMatchingRefSpecError{}):
+			a.Logger.Warn("Pull request branch does not exist, recreating it", zap.String("branch", branch))
+		default:
+			return false, err
+		}
+	}
+
+	if err := tree.Checkout(opts); err != nil {
+		return false, fmt.Errorf("create branch %s: %w", branch, err)
+	}
+	return force, nil
+}
+
+// writeBackRepository returns the clone URL and token of the repository
+// configurations are written back to. This is the output repository when
+// one is set, otherwise the repository the action is running for.
+func (a *Action) writeBackRepository() (string, string, error) {
+	if a.outputRepositoryURL == "" {
+		return repo.CloneURL(a.githubURL, a.githubToken), a.githubToken, nil
+	}
+
+	cloneURL, err := repo.AuthURL(a.outputRepositoryURL, a.outputRepositoryToken)
+	if err != nil {
+		return "", "", fmt.Errorf("output repository: %w", err)
+	}
+	return cloneURL, a.outputRepositoryToken, nil
+}
+
+// writeBackConfigurationNames returns the sorted names of configurations to
+// render. These are the configurations applied during this run, and those
+// on the server which reference an applied source, processor or destination.
+// When write back of all configurations is enabled, every configuration on
+// the server is returned.
+func (a *Action) writeBackConfigurationNames() ([]string, error) {
+	names := make(map[string]struct{})
+	for _, name := range a.state.ConfigurationNames() {
+		names[name] = struct{}{}
+	}
+
+	applied := map[model.Kind]map[string]struct{}{}
+	for _, kind := range []model.Kind{model.KindSource, model.KindProcessor, model.KindDestination} {
+		for _, name := range a.state.ResourceNames(kind) {
+			if _, ok := applied[kind]; !ok {
+				applied[kind] = make(map[string]struct{})
+			}
+			applied[kind][name] = struct{}{}
+		}
+	}
+
+	if a.writeBackAll || len(applied) > 0 {
+		configurations, err := a.client.Configurations(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("list configurations: %w", err)
+		}
+
+		for _, c := range configurations {
+			if c == nil {
+				continue
+			}
+			if a.writeBackAll || referencesResources(c, applied) {
+				names[c.Metadata.Name] = struct{}{}
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+// referencesResources returns true if the configuration references any of
+// the given resources by name. Processors are checked within each source
+// and destination.
+func referencesResources(c *model.Configuration, resources map[model.Kind]map[string]struct{}) bool {
+	var references func(kind model.Kind, rcs []model.ResourceConfiguration) bool
+	references = func(kind model.Kind, rcs []model.ResourceConfiguration) bool {
+		for _, rc := range rcs {
+			if rc.Name != "" {
+				if _, ok := resources[kind][model.TrimVersion(rc.Name)]; ok {
+					return true
+				}
+			}
+			if references(model.KindProcessor, rc.Processors) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return references(model.KindSource, c.Spec.Sources) ||
+		references(model.KindDestination, c.Spec.Destinations)
+}
+
+// writeConfigurations writes each raw configuration to the configuration
+// output directory of the worktree and stages the changes. Outputs recorded
+// in the manifest are removed when their configuration no longer exists. The
+// sorted names of configurations which changed are returned, along with
+// whether any change was staged, including changes to the manifest alone.
+func (a *Action) writeConfigurations(tree *git.Worktree, rawConfigs map[string]string) ([]string, bool, error) {
+	root := tree.Filesystem.Root()
+	outputDir := filepath.Join(root, a.configurationOutputDir)
+
+	// Create the directory if it doesn't exist. MkdirAll will return
+	// nil if the directory already exists. Returns an error if something
+	// goes wrong.
+	if err := os.MkdirAll(outputDir, 0750); err != nil {
+		return nil, false, fmt.Errorf("create directory %s: %w", outputDir, err)
+	}
+
+	previous, err := readManifest(outputDir)
+	if err != nil {
+		return nil, false, err
+	}
+	current := &manifest{Configurations: make(map[string][]string, len(rawConfigs))}
+
+	// paths maps the path of each file, relative to the worktree,
+	// to the name of the configuration it renders
+	paths := make(map[string]string, len(rawConfigs))
+	relPath := func(file string) string {
+		return filepath.ToSlash(filepath.Join(a.configurationOutputDir, file))
+	}
+
+	for name, rawConfig := range rawConfigs {
+		outputs, err := renderOutputs(a.configurationOutputFormat, name, rawConfig)
+		if err != nil {
+			return nil, false, err
+		}
+
+		files := make([]string, 0, len(outputs))
+		for file, contents := range outputs {
+			path := filepath.Join(outputDir, filepath.FromSlash(file))
+			if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+				return nil, false, fmt.Errorf("create directory %s: %w", filepath.Dir(path), err)
+			}
+
+			if err := os.WriteFile(path, contents, 0600); err != nil {
+				return nil, false, fmt.Errorf("write file %s: %w", path, err)
+			}
+
+			files = append(files, file)
+			paths[relPath(file)] = name
+			a.Logger.Info("Raw configuration written to file", zap.String("name", name), zap.String("path", relPath(file)))
+		}
+
+		sort.Strings(files)
+		current.Configurations[name] = files
+	}
+
+	// Reconcile the outputs owned by the action. Configurations which were
+	// not rendered during this run keep their outputs if they still exist.
+	for name, files := range previous.Configurations {
+		if _, ok := current.Configurations[name]; !ok {
+			exists, err := a.configurationExists(name)
+			if err != nil {
+				return nil, false, err
+			}
+			if exists {
+				current.Configurations[name] = files
+				continue
+			}
+			a.Logger.Info("Removing outputs for configuration which no longer exists", zap.String("name", name))
+		}
+
+		for _, file := range files {
+			if slices.Contains(current.Configurations[name], file) {
+				continue
+			}
+
+			if !filepath.IsLocal(file) {
+				a.Logger.Warn("Skipping manifest entry outside of the output directory", zap.String("name", name), zap.String("path", file))
+				continue
+			}
+
+			path := filepath.Join(outputDir, filepath.FromSlash(file))
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, false, fmt.Errorf("remove stale output %s: %w", relPath(file), err)
+			}
+
+			// Remove the directory of split outputs once it is empty,
+			// this fails without side effects when it is not
+			if dir := filepath.Dir(path); dir != outputDir {
+				_ = os.Remove(dir)
+			}
+			paths[relPath(file)] = name
+			a.Logger.Info("Removed stale output", zap.String("name", name), zap.String("path", relPath(file)))
+		}
+	}
+
+	if err := current.write(outputDir); err != nil {
+		return nil, false, err
+	}
+
+	status, err := tree.Status()
+	if err != nil {
+		return nil, false, fmt.Errorf("get work tree status: %w", err)
+	}
+
+	changed := map[string]struct{}{}
+	for path, s := range status {
+		a.Logger.Info("file changed", zap.String("path", path))
+		if s.Worktree == git.Deleted {
+			if _, err := tree.Remove(path); err != nil {
+				return nil, false, fmt.Errorf("git rm file %s: %w", path, err)
+			}
+		} else if _, err := tree.Add(path); err != nil {
+			return nil, false, fmt.Errorf("git add file %s: %w", path, err)
+		}
+		if name, ok := paths[path]; ok {
+			changed[name] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(changed))
+	for name := range changed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, len(status) > 0, nil
+}
+
+// configurationExists returns true if the configuration exists in BindPlane
+func (a *Action) configurationExists(name string) (bool, error) {
+	_, err := a.client.Configuration(context.Background(), name)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, client.ErrNotFound):
+		return false, nil
+	default:
+		return false, fmt.Errorf("get configuration %s: %w", name, err)
+	}
+}
+
+// githubClient returns a GitHub API client and the owner and
+// name of the repository referenced by cloneURL
+func (a *Action) githubClient(cloneURL, token string) (*github.Client, string, string, error) {
+	owner, name, err := repo.Slug(cloneURL)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	client, err := github.New(a.githubAPIURL, token, a.config.Network.Proxy)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("create github client: %w", err)
+	}
+	return client, owner, name, nil
+}
+
+// findPullRequest returns the open pull request from branch into the
+// configuration output branch, or nil if there is none
+func (a *Action) findPullRequest(cloneURL, token, branch string) (*github.PullRequest, error) {
+	client, owner, name, err := a.githubClient(cloneURL, token)
+	if err != nil {
+		return nil, err
+	}
+	return client.FindPullRequest(context.Background(), owner, name, branch, a.configurationOutputBranch)
+}
+
+// openPullRequest opens a pull request from branch into the configuration
+// output branch, or updates the existing pull request. The first line of
+// the commit message is used as the pull request title. The body of an
+// existing pull request keeps listing the configurations it changed.
+func (a *Action) openPullRequest(cloneURL, token, branch, message string, changed []string, existing *github.PullRequest) error {
+	client, owner, name, err := a.githubClient(cloneURL, token)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	title, _, _ := strings.Cut(message, "\n")
+
+	if existing != nil {
+		changed = append(pullRequestConfigurations(existing.Body), changed...)
+		slices.Sort(changed)
+		changed = slices.Compact(changed)
+
+		body := pullRequestBody(a.configurationOutputDir, changed)
+		pr, err := client.UpdatePullRequest(ctx, owner, name, existing.Number, title, body)
+		if err != nil {
+			return err
+		}
+		a.Logger.Info("Updated pull request", zap.Int("number", pr.Number), zap.String("url", pr.HTMLURL))
+		return nil
+	}
+
+	pr, err := client.CreatePullRequest(ctx, owner, name, github.NewPullRequest{
+		Title: title,
+		Head:  branch,
+		Base:  a.configurationOutputBranch,
+		Body:  pullRequestBody(a.configurationOutputDir, changed),
+	})
+	if err != nil {
+		return err
+	}
+	a.Logger.Info("Opened pull request", zap.Int("number", pr.Number), zap.String("url", pr.HTMLURL))
+
+	return nil
+}
+
+// pullRequestBody returns the markdown body of a write back pull request
+func pullRequestBody(outputDir string, changed []string) string {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "This pull request was opened by the Bindplane action to update the rendered OpenTelemetry configurations in `%s`.\n\n", outputDir)
+	if len(changed) == 0 {
+		fmt.Fprintf(&b, "No configurations changed, only the `%s` manifest was updated.\n", manifestFile)
+		return b.String()
+	}
+	b.WriteString("Changed configurations:\n\n")
+	for _, name := range changed {
+		fmt.Fprintf(&b, "- `%s`\n", name)
+	}
+	return b.String()
+}
+
+// pullRequestConfigurations returns the configurations listed in
+// the body of a pull request opened by the action
+func pullRequestConfigurations(body string) []string {
+	names := []string{}
+	for _, line := range strings.Split(body, "\n") {
+		name, ok := strings.CutPrefix(strings.TrimSpace(line), "- `")
+		if !ok {
+			continue
+		}
+		if name, ok = strings.CutSuffix(name, "`"); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
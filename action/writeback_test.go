@@ -0,0 +1,712 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"github.com/observiq/bindplane-op-action/internal/github"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gopkg.in/yaml.v3"
+)
+
+// newTestRemote creates a bare repository at <tmp>/observIQ/configs.git
+// with a single commit on branch containing the given files
+func newTestRemote(t *testing.T, branch string, files map[string]string) string {
+	t.Helper()
+
+	tmp := t.TempDir()
+	remote := filepath.Join(tmp, "observIQ", "configs.git")
+	_, err := git.PlainInit(remote, true)
+	require.NoError(t, err)
+
+	seed, err := git.PlainInit(filepath.Join(tmp, "seed"), false)
+	require.NoError(t, err)
+	require.NoError(t, seed.Storer.SetReference(
+		plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch)),
+	))
+
+	tree, err := seed.Worktree()
+	require.NoError(t, err)
+
+	if files == nil {
+		files = map[string]string{"README.md": "configs"}
+	}
+	for path, contents := range files {
+		full := filepath.Join(tmp, "seed", path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0750))
+		require.NoError(t, os.WriteFile(full, []byte(contents), 0600))
+		_, err := tree.Add(path)
+		require.NoError(t, err)
+	}
+
+	_, err = tree.Commit("initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	_, err = seed.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{remote}})
+	require.NoError(t, err)
+	require.NoError(t, seed.Push(&git.PushOptions{RemoteName: "origin"}))
+
+	return remote
+}
+
+// remoteFiles returns the files on branch of the remote repository
+func remoteFiles(t *testing.T, remote, branch string) map[string]string {
+	t.Helper()
+
+	r, err := git.PlainOpen(remote)
+	require.NoError(t, err)
+
+	ref, err := r.Reference(plumbing.NewBranchReferenceName(branch), true)
+	require.NoError(t, err)
+
+	commit, err := r.CommitObject(ref.Hash())
+	require.NoError(t, err)
+
+	tree, err := commit.Tree()
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		contents, err := f.Contents()
+		files[f.Name] = contents
+		return err
+	})
+	require.NoError(t, err)
+
+	return files
+}
+
+// headCommit returns the head commit of branch in the remote repository
+func headCommit(t *testing.T, remote, branch string) *object.Commit {
+	t.Helper()
+
+	r, err := git.PlainOpen(remote)
+	require.NoError(t, err)
+
+	ref, err := r.Reference(plumbing.NewBranchReferenceName(branch), true)
+	require.NoError(t, err)
+
+	commit, err := r.CommitObject(ref.Hash())
+	require.NoError(t, err)
+	return commit
+}
+
+// newTestBindPlane returns a fake BindPlane server which serves the
+// given raw configurations
+func newTestBindPlane(t *testing.T, rawConfigs map[string]string) *httptest.Server {
+	t.Helper()
+	return newTestBindPlaneWithSpecs(t, rawConfigs, nil)
+}
+
+// newTestBindPlaneWithSpecs is newTestBindPlane, with configurations listed by
+// GET /v1/configurations using the given specs
+func newTestBindPlaneWithSpecs(t *testing.T, rawConfigs map[string]string, specs map[string]model.ConfigurationSpec) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/configurations", func(w http.ResponseWriter, _ *http.Request) {
+		resp := model.ConfigurationsResponse{}
+		for name := range rawConfigs {
+			c := &model.Configuration{Spec: specs[name]}
+			c.Metadata.Name = name
+			resp.Configurations = append(resp.Configurations, c)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		raw, ok := rawConfigs[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		c := &model.Configuration{}
+		c.Metadata.Name = name
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: c, Raw: raw})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestAction returns an action connected to the BindPlane server
+// with the given configurations in its state
+func newTestAction(t *testing.T, server *httptest.Server, configurations []string, opts ...Option) *Action {
+	t.Helper()
+
+	opts = append([]Option{WithBindPlaneRemoteURL(server.URL), WithBindPlaneAPIKey("key")}, opts...)
+	a, err := New(zap.NewNop(), opts...)
+	require.NoError(t, err)
+
+	for _, name := range configurations {
+		r := model.AnyResource{}
+		r.Kind = string(model.KindConfiguration)
+		r.Metadata.Name = name
+		a.state.SetConfiguration(name, r)
+	}
+
+	return a
+}
+
+func TestWriteBackPush(t *testing.T) {
+	remote := newTestRemote(t, "main", nil)
+	server := newTestBindPlane(t, map[string]string{
+		"gateway": "receivers: {}\n",
+		"node":    "exporters: {}\n",
+	})
+
+	a := newTestAction(t, server, []string{"gateway", "node"},
+		WithOTELConfigWriteBack(true),
+		WithConfigurationOutputDir("otel/"),
+		WithConfigurationOutputBranch("main"),
+		WithGithubURL(remote),
+		WithWriteBackMode(WriteBackModePush),
+	)
+
+	require.NoError(t, a.WriteBack())
+	files := remoteFiles(t, remote, "main")
+	require.Contains(t, files, "otel/"+manifestFile)
+	delete(files, "otel/"+manifestFile)
+	require.Equal(t, map[string]string{
+		"README.md":         "configs",
+		"otel/gateway.yaml": "receivers: {}\n",
+		"otel/node.yaml":    "exporters: {}\n",
+	}, files)
+
+	// A second write back without changes does not create a commit
+	head := headCommit(t, remote, "main")
+	require.NoError(t, a.WriteBack())
+	require.Equal(t, head.Hash, headCommit(t, remote, "main").Hash)
+}
+
+func TestWriteBackPullRequest(t *testing.T) {
+	remote := newTestRemote(t, "main", map[string]string{
+		"otel/gateway.yaml": "receivers: {}\n",
+	})
+	rawConfigs := map[string]string{
+		"gateway": "receivers: {}\n",
+		"node":    "exporters: {}\n",
+	}
+	server := newTestBindPlane(t, rawConfigs)
+
+	var created github.NewPullRequest
+	var updatedBody string
+	open := false
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/observIQ/configs/pulls", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "observIQ:bindplane-op-action/main-otel", r.URL.Query().Get("head"))
+		prs := []github.PullRequest{}
+		if open {
+			prs = append(prs, github.PullRequest{Number: 1, Body: pullRequestBody("otel", []string{"node"})})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(prs)
+	})
+	mux.HandleFunc("POST /repos/observIQ/configs/pulls", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		open = true
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(github.PullRequest{Number: 1})
+	})
+	mux.HandleFunc("PATCH /repos/observIQ/configs/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		updatedBody = body["body"]
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(github.PullRequest{Number: 1})
+	})
+	githubAPI := httptest.NewServer(mux)
+	defer githubAPI.Close()
+
+	opts := []Option{
+		WithOTELConfigWriteBack(true),
+		WithConfigurationOutputDir("otel"),
+		WithConfigurationOutputBranch("main"),
+		WithGithubURL(remote),
+		WithGithubToken("token"),
+		WithGithubAPIURL(githubAPI.URL),
+		WithWriteBackMode(WriteBackModePullRequest),
+	}
+	a := newTestAction(t, server, []string{"gateway", "node"}, opts...)
+	require.NoError(t, a.WriteBack())
+
+	// The output branch is untouched and the changes are pushed to
+	// the pull request branch of the configuration output directory
+	require.NotContains(t, remoteFiles(t, remote, "main"), "otel/node.yaml")
+	require.Equal(t, "exporters: {}\n", remoteFiles(t, remote, "bindplane-op-action/main-otel")["otel/node.yaml"])
+
+	require.Equal(t, "bindplane-op-action/main-otel", created.Head)
+	require.Equal(t, "main", created.Base)
+	require.Contains(t, created.Body, "- `node`")
+	require.False(t, strings.Contains(created.Body, "`gateway`"), "unchanged configurations should not be listed")
+
+	// A second run which only renders agent builds on the open pull
+	// request, keeping the unmerged node configuration
+	first := headCommit(t, remote, "bindplane-op-action/main-otel")
+	rawConfigs["agent"] = "processors: {}\n"
+	a = newTestAction(t, server, []string{"agent"}, opts...)
+	require.NoError(t, a.WriteBack())
+
+	files := remoteFiles(t, remote, "bindplane-op-action/main-otel")
+	require.Equal(t, "exporters: {}\n", files["otel/node.yaml"])
+	require.Equal(t, "processors: {}\n", files["otel/agent.yaml"])
+	require.Equal(t, []plumbing.Hash{first.Hash}, headCommit(t, remote, "bindplane-op-action/main-otel").ParentHashes)
+	require.Contains(t, updatedBody, "- `agent`")
+	require.Contains(t, updatedBody, "- `node`")
+}
+
+func TestPullRequestBranch(t *testing.T) {
+	cases := []struct {
+		outputDir string
+		expected  string
+	}{
+		{"", "bindplane-op-action/main"},
+		{".", "bindplane-op-action/main"},
+		{"otel/", "bindplane-op-action/main-otel"},
+		{"otel/staging", "bindplane-op-action/main-otel-staging"},
+		{"./deploy configs", "bindplane-op-action/main-deploy-configs"},
+	}
+
+	for _, tc := range cases {
+		a := &Action{configurationOutputBranch: "main", configurationOutputDir: tc.outputDir}
+		require.Equal(t, tc.expected, a.pullRequestBranch(), tc.outputDir)
+	}
+}
+
+func TestWriteBackRemovesStaleOutputs(t *testing.T) {
+	remote := newTestRemote(t, "main", map[string]string{
+		"otel/" + manifestFile:  "configurations:\n  gateway: [gateway.yaml]\n  deleted: [deleted.yaml]\n",
+		"otel/gateway.yaml":     "receivers: {}\n",
+		"otel/deleted.yaml":     "receivers: {}\n",
+		"otel/handwritten.yaml": "receivers: {}\n",
+		"otel/unmanaged.yaml":   "receivers: {}\n",
+	})
+
+	// gateway exists but was not applied during this run,
+	// deleted and unmanaged no longer exist
+	server := newTestBindPlane(t, map[string]string{
+		"gateway": "receivers: {}\n",
+		"node":    "exporters: {}\n",
+	})
+
+	a := newTestAction(t, server, []string{"node"},
+		WithOTELConfigWriteBack(true),
+		WithConfigurationOutputDir("otel"),
+		WithConfigurationOutputBranch("main"),
+		WithGithubURL(remote),
+		WithWriteBackMode(WriteBackModePush),
+	)
+
+	require.NoError(t, a.WriteBack())
+
+	files := remoteFiles(t, remote, "main")
+	require.NotContains(t, files, "otel/deleted.yaml")
+	require.Contains(t, files, "otel/gateway.yaml")
+	require.Contains(t, files, "otel/handwritten.yaml")
+	require.Contains(t, files, "otel/unmanaged.yaml")
+	require.Equal(t, "exporters: {}\n", files["otel/node.yaml"])
+
+	m := manifest{}
+	require.NoError(t, yaml.Unmarshal([]byte(files["otel/"+manifestFile]), &m))
+	require.Equal(t, map[string][]string{
+		"gateway": {"gateway.yaml"},
+		"node":    {"node.yaml"},
+	}, m.Configurations)
+}
+
+func TestWriteBackManifestOnly(t *testing.T) {
+	// The rendered configuration already matches, so only the
+	// manifest is added, as for repositories written before it
+	remote := newTestRemote(t, "main", map[string]string{
+		"otel/gateway.yaml": "receivers: {}\n",
+	})
+	server := newTestBindPlane(t, map[string]string{
+		"gateway": "receivers: {}\n",
+	})
+
+	a := newTestAction(t, server, []string{"gateway"},
+		WithOTELConfigWriteBack(true),
+		WithConfigurationOutputDir("otel"),
+		WithConfigurationOutputBranch("main"),
+		WithGithubURL(remote),
+		WithWriteBackMode(WriteBackModePush),
+	)
+
+	head := headCommit(t, remote, "main")
+	require.NoError(t, a.WriteBack())
+
+	files := remoteFiles(t, remote, "main")
+	require.Contains(t, files, "otel/"+manifestFile)
+	require.Equal(t, []plumbing.Hash{head.Hash}, headCommit(t, remote, "main").ParentHashes)
+}
+
+func TestWriteBackAffectedConfigurations(t *testing.T) {
+	rawConfigs := map[string]string{
+		"gateway": "receivers: {}\n",
+		"node":    "exporters: {}\n",
+		"edge":    "processors: {}\n",
+		"other":   "service: {}\n",
+	}
+	specs := map[string]model.ConfigurationSpec{
+		"node": {
+			Destinations: []model.ResourceConfiguration{{Name: "otlp:2"}},
+		},
+		"edge": {
+			Sources: []model.ResourceConfiguration{{
+				ParameterizedSpec: model.ParameterizedSpec{
+					Type:       "journald",
+					Processors: []model.ResourceConfiguration{{Name: "batch"}},
+				},
+			}},
+		},
+		"other": {
+			Destinations: []model.ResourceConfiguration{{Name: "logging"}},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{
+			name:     "affected",
+			expected: []string{"edge", "gateway", "node"},
+		},
+		{
+			name:     "all",
+			opts:     []Option{WithWriteBackAllConfigurations(true)},
+			expected: []string{"edge", "gateway", "node", "other"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			remote := newTestRemote(t, "main", nil)
+			server := newTestBindPlaneWithSpecs(t, rawConfigs, specs)
+
+			opts := append([]Option{
+				WithOTELConfigWriteBack(true),
+				WithConfigurationOutputDir("otel"),
+				WithConfigurationOutputBranch("main"),
+				WithGithubURL(remote),
+			}, tc.opts...)
+			a := newTestAction(t, server, []string{"gateway"}, opts...)
+			a.state.AddResource(model.KindDestination, "otlp")
+			a.state.AddResource(model.KindProcessor, "batch")
+
+			require.NoError(t, a.WriteBack())
+
+			files := remoteFiles(t, remote, "main")
+			var rendered []string
+			for path := range files {
+				if strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, manifestFile) {
+					rendered = append(rendered, strings.TrimSuffix(strings.TrimPrefix(path, "otel/"), ".yaml"))
+				}
+			}
+			require.ElementsMatch(t, tc.expected, rendered)
+		})
+	}
+}
+
+func TestWriteBackOutputRepository(t *testing.T) {
+	source := newTestRemote(t, "main", nil)
+	output := newTestRemote(t, "deploy", nil)
+	server := newTestBindPlane(t, map[string]string{
+		"gateway": "receivers: {}\n",
+	})
+
+	a := newTestAction(t, server, []string{"gateway"},
+		WithOTELConfigWriteBack(true),
+		WithConfigurationOutputDir("otel"),
+		WithConfigurationOutputBranch("deploy"),
+		WithGithubURL(source),
+		WithOutputRepositoryURL(output),
+	)
+
+	sourceHead := headCommit(t, source, "main")
+	require.NoError(t, a.WriteBack())
+
+	require.Equal(t, sourceHead.Hash, headCommit(t, source, "main").Hash)
+	require.Equal(t, "receivers: {}\n", remoteFiles(t, output, "deploy")["otel/gateway.yaml"])
+}
+
+// pushTestCommit pushes a commit containing the given files
+// to branch of the remote repository
+func pushTestCommit(t *testing.T, remote, branch string, files map[string]string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	r, err := git.PlainClone(dir, false, &git.CloneOptions{
+		URL:           remote,
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		SingleBranch:  true,
+	})
+	require.NoError(t, err)
+
+	tree, err := r.Worktree()
+	require.NoError(t, err)
+
+	for path, contents := range files {
+		full := filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0750))
+		require.NoError(t, os.WriteFile(full, []byte(contents), 0600))
+		_, err := tree.Add(path)
+		require.NoError(t, err)
+	}
+
+	_, err = tree.Commit("concurrent commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test", When: time.Now()},
+	})
+	require.NoError(t, err)
+	require.NoError(t, r.Push(&git.PushOptions{RemoteName: "origin"}))
+}
+
+func TestWriteBackPushRetry(t *testing.T) {
+	cases := []struct {
+		name      string
+		retries   int
+		expectErr string
+	}{
+		{
+			name:    "retry",
+			retries: 2,
+		},
+		{
+			name:      "no-retries",
+			retries:   0,
+			expectErr: "push changes: non-fast-forward update",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The manifest lists a configuration which is not rendered, so
+			// write back checks that it exists after cloning. The check is
+			// used to push a concurrent commit before the first push.
+			remote := newTestRemote(t, "main", map[string]string{
+				"otel/" + manifestFile: "configurations:\n  other: [other.yaml]\n",
+				"otel/other.yaml":      "service: {}\n",
+			})
+
+			concurrent := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+				name := r.PathValue("name")
+				raw := "receivers: {}\n"
+				if name == "other" {
+					raw = "service: {}\n"
+					if concurrent == 0 {
+						pushTestCommit(t, remote, "main", map[string]string{"otel/manual.yaml": "exporters: {}\n"})
+					}
+					concurrent++
+				}
+
+				c := &model.Configuration{}
+				c.Metadata.Name = name
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: c, Raw: raw})
+			})
+			server := httptest.NewServer(mux)
+			t.Cleanup(server.Close)
+
+			a := newTestAction(t, server, []string{"gateway"},
+				WithOTELConfigWriteBack(true),
+				WithConfigurationOutputDir("otel"),
+				WithConfigurationOutputBranch("main"),
+				WithGithubURL(remote),
+				WithPushRetries(tc.retries),
+			)
+			a.pushRetryBackoff = time.Millisecond
+
+			err := a.WriteBack()
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 2, concurrent)
+
+			files := remoteFiles(t, remote, "main")
+			require.Equal(t, "receivers: {}\n", files["otel/gateway.yaml"])
+			require.Equal(t, "exporters: {}\n", files["otel/manual.yaml"])
+			require.Equal(t, "service: {}\n", files["otel/other.yaml"])
+
+			head := headCommit(t, remote, "main")
+			require.Len(t, head.ParentHashes, 1)
+			parent, err := head.Parent(0)
+			require.NoError(t, err)
+			require.Equal(t, "concurrent commit", parent.Message)
+		})
+	}
+}
+
+func TestWriteBackWorkspace(t *testing.T) {
+	cases := []struct {
+		name  string
+		dirty bool
+		mode  string
+	}{
+		{
+			name: "workspace",
+			mode: WriteBackModePush,
+		},
+		{
+			name: "dirty-workspace",
+			mode: WriteBackModePush,
+			// Changes in the workspace must not be committed
+			dirty: true,
+		},
+		{
+			name: "pull-request",
+			mode: WriteBackModePullRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			remote := newTestRemote(t, "main", nil)
+			server := newTestBindPlane(t, map[string]string{
+				"gateway": "receivers: {}\n",
+			})
+
+			workspace := t.TempDir()
+			ws, err := git.PlainClone(workspace, false, &git.CloneOptions{
+				URL:           remote,
+				ReferenceName: plumbing.NewBranchReferenceName("main"),
+			})
+			require.NoError(t, err)
+			if tc.dirty {
+				require.NoError(t, os.WriteFile(filepath.Join(workspace, "README.md"), []byte("local change"), 0600))
+			}
+			workspaceHead, err := ws.Head()
+			require.NoError(t, err)
+
+			// A concurrent commit makes the first push fail, so
+			// the retry resets the clone to the remote branch
+			pushTestCommit(t, remote, "main", map[string]string{"otel/manual.yaml": "exporters: {}\n"})
+
+			opts := []Option{
+				WithOTELConfigWriteBack(true),
+				WithConfigurationOutputDir("otel"),
+				WithConfigurationOutputBranch("main"),
+				WithGithubURL(remote),
+				WithWriteBackMode(tc.mode),
+				WithWorkspace(workspace),
+				WithCloneDepth(1),
+				WithPushRetries(1),
+			}
+			if tc.mode == WriteBackModePullRequest {
+				api := httptest.NewServer(http.NotFoundHandler())
+				t.Cleanup(api.Close)
+				opts = append(opts, WithGithubAPIURL(api.URL), WithGithubToken("token"))
+			}
+			a := newTestAction(t, server, []string{"gateway"}, opts...)
+			a.pushRetryBackoff = time.Millisecond
+
+			core, logs := observer.New(zap.DebugLevel)
+			a.Logger = zap.New(core)
+
+			err = a.WriteBack()
+			require.Equal(t, 1, logs.FilterMessage("Cloned workspace checkout").Len())
+			if tc.mode == WriteBackModePullRequest {
+				// The fake GitHub API rejects looking up the pull request
+				require.ErrorContains(t, err, "pull request")
+			} else {
+				require.NoError(t, err)
+				files := remoteFiles(t, remote, "main")
+				require.Equal(t, "receivers: {}\n", files["otel/gateway.yaml"])
+				require.Equal(t, "exporters: {}\n", files["otel/manual.yaml"])
+				require.Equal(t, "configs", files["README.md"])
+			}
+
+			// The workspace is only read
+			head, err := ws.Head()
+			require.NoError(t, err)
+			require.Equal(t, workspaceHead.Hash(), head.Hash())
+			require.NoDirExists(t, filepath.Join(workspace, "otel"))
+			if tc.dirty {
+				b, err := os.ReadFile(filepath.Join(workspace, "README.md")) // #nosec G304 test file
+				require.NoError(t, err)
+				require.Equal(t, "local change", string(b))
+			}
+		})
+	}
+}
+
+func TestWriteBackValidateRenderedConfigurations(t *testing.T) {
+	valid := "receivers:\n  otlp: {}\nexporters:\n  logging: {}\nservice:\n  pipelines:\n    logs:\n      receivers: [otlp]\n      exporters: [logging]\n"
+
+	cases := []struct {
+		name      string
+		validate  bool
+		expectErr []string
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:     "enabled",
+			validate: true,
+			expectErr: []string{
+				"rendered configurations are invalid",
+				"configuration edge: service has no pipelines",
+				"configuration node: pipeline metrics exporters references otlp, which is not defined",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			remote := newTestRemote(t, "main", nil)
+			server := newTestBindPlane(t, map[string]string{
+				"gateway": valid,
+				"edge":    "service: {}\n",
+				"node":    "receivers:\n  otlp: {}\nservice:\n  pipelines:\n    metrics:\n      receivers: [otlp]\n      exporters: [otlp]\n",
+			})
+
+			a := newTestAction(t, server, []string{"gateway", "edge", "node"},
+				WithOTELConfigWriteBack(true),
+				WithConfigurationOutputDir("otel"),
+				WithConfigurationOutputBranch("main"),
+				WithGithubURL(remote),
+				WithValidateRenderedConfigurations(tc.validate),
+			)
+
+			head := headCommit(t, remote, "main")
+			err := a.WriteBack()
+			if len(tc.expectErr) == 0 {
+				require.NoError(t, err)
+				require.Contains(t, remoteFiles(t, remote, "main"), "otel/edge.yaml")
+				return
+			}
+
+			for _, e := range tc.expectErr {
+				require.ErrorContains(t, err, e)
+			}
+			require.NotContains(t, err.Error(), "configuration gateway")
+			require.Equal(t, head.Hash, headCommit(t, remote, "main").Hash)
+		})
+	}
+}
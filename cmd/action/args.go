@@ -1,88 +1,451 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"path"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/observiq/bindplane-op-action/action"
+	"github.com/observiq/bindplane-op-action/internal/client"
+	"github.com/observiq/bindplane-op-action/internal/glob"
+	"github.com/observiq/bindplane-op-action/internal/logging"
+	"github.com/observiq/bindplane-op-action/internal/repo"
+	"gopkg.in/yaml.v3"
 )
 
-// parseArgs parses the arguments passed to the action. The action will always
-// pass the correct number of arguments regardless of the user input. If the
-// number of args passed is not expected, an error will be returned indicating
-// a bug with the action.
-func parseArgs() error {
-	args := os.Args
-
-	// Add one to account for arg 0 being the binary name
-	count := argCount + 1
-	if len(args) != count {
-		return fmt.Errorf("Not enough arguments, expected 20, got %d. %s.", len(args), action.BugError)
-	}
-
-	// First arg is always the binary name, so we skip it. We could
-	// also use args[1:] to get all args after the binary name but
-	// that could introduce confusion as the first arg would be at index 0.
-	_ = args[0]
-
-	// Order matters. The action will pass in the exact order defined
-	// in action.yml in the root of the action repository.
-	bindplane_remote_url = args[1]
-	bindplane_api_key = args[2]
-	bindplane_username = args[3]
-	bindplane_password = args[4]
-	target_branch = args[5]
-	destination_path = args[6]
-	configuration_path = args[7]
-
-	b, err := strconv.ParseBool(args[8])
-	if err != nil {
-		return fmt.Errorf("enable_otel_config_write_back must be a boolean value")
+// defaultCloneDepth is used when clone_depth is not set. Reading the
+// commit message and writing back only require the head commit.
+const defaultCloneDepth = 1
+
+// defaultLogLevel is used when log_level is not set
+const defaultLogLevel = "info"
+
+// input is a named input of the action. GitHub passes each input defined
+// in action.yml to the container as an INPUT_<NAME> environment variable.
+type input struct {
+	name  string
+	usage string
+
+	// def is used when the input is not set or is empty
+	def string
+
+	// set parses the value and assigns it to the input's global variable
+	set func(value string) error
+}
+
+// inputs returns the inputs of the action. New inputs should be added here
+// and to action.yml with the same name.
+func inputs() []input {
+	return []input{
+		stringInput("bindplane_remote_url", "The URL used to connect to Bindplane", "", &bindplane_remote_url),
+		stringInput("bindplane_api_key", "The Bindplane API key", "", &bindplane_api_key),
+		stringInput("bindplane_username", "The Bindplane username", "", &bindplane_username),
+		stringInput("bindplane_password", "The Bindplane password", "", &bindplane_password),
+		stringInput("bindplane_oidc_token_url", "The token endpoint used to exchange a GitHub Actions OIDC token", "", &bindplane_oidc_token_url),
+		stringInput("bindplane_oidc_audience", "The audience of the GitHub Actions OIDC token", "", &bindplane_oidc_audience),
+		stringInput("target_branch", "The branch resources are applied from", "", &target_branch),
+		stringInput("destination_path", "Path to the destination resource file(s)", "", &destination_path),
+		stringInput("source_path", "Path to the source resource file(s)", "", &source_path),
+		stringInput("processor_path", "Path to the processor resource file(s)", "", &processor_path),
+		stringInput("connector_path", "Path to the connector resource file(s)", "", &connector_path),
+		stringInput("fleet_path", "Path to the fleet resource file(s)", "", &fleet_path),
+		stringInput("configuration_path", "Path to the configuration resource file(s)", "", &configuration_path),
+		boolInput("enable_otel_config_write_back", "Write rendered configurations back to the repository", false, &enable_otel_config_write_back),
+		stringInput("configuration_output_dir", "Directory rendered configurations are written to", "", &configuration_output_dir),
+		stringInput("configuration_output_branch", "Branch rendered configurations are written to, defaults to target_branch", "", &configuration_output_branch),
+		stringInput("write_back_mode", "How rendered configurations are written back, push or pull_request", action.WriteBackModePush, &write_back_mode),
+		stringInput("github_api_url", "The GitHub API URL used to open pull requests", "", &github_api_url),
+		intInput("clone_depth", "Number of commits fetched when cloning, 0 fetches the full history", defaultCloneDepth, &clone_depth),
+		durationInput("clone_timeout", "Maximum time a clone may take", repo.DefaultCloneTimeout, &clone_timeout),
+		intInput("push_retries", "Number of times a rejected write back push is retried", action.DefaultPushRetries, &push_retries),
+		stringInput("output_repository_url", "Repository rendered configurations are written to instead of github_url", "", &output_repository_url),
+		stringInput("output_repository_token", "Token used to push to output_repository_url", "", &output_repository_token),
+		stringInput("configuration_output_format", "Format of rendered configurations: raw, configmap, helm or split", action.OutputFormatRaw, &configuration_output_format),
+		boolInput("validate_rendered_configurations", "Validate rendered configurations before writing them back", false, &validate_rendered_configs),
+		boolInput("redact_rendered_configurations", "Replace secrets in rendered configurations with environment variable references", false, &redact_rendered_configs),
+		listInput("redact_key_patterns", "Comma separated key patterns redacted from rendered configurations", action.DefaultRedactKeyPatterns, &redact_key_patterns),
+		boolInput("write_back_all_configurations", "Write back every configuration instead of only affected ones", false, &write_back_all_configurations),
+		stringInput("commit_author_name", "Author name of write back commits", action.DefaultCommitAuthorName, &commit_author_name),
+		stringInput("commit_author_email", "Author email of write back commits", action.DefaultCommitAuthorEmail, &commit_author_email),
+		stringInput("commit_message_template", "Go template of write back commit messages", action.DefaultCommitMessageTemplate, &commit_message_template),
+		stringInput("commit_signing_key", "Armored PGP or SSH private key used to sign write back commits", "", &commit_signing_key),
+		stringInput("commit_signing_key_passphrase", "Passphrase of commit_signing_key", "", &commit_signing_key_passphrase),
+		stringInput("token", "GitHub token used to read and write the repository", "", &token),
+		boolInput("enable_auto_rollout", "Start a rollout for configurations after they are applied", false, &enable_auto_rollout),
+		stringInput("tls_ca_cert", "PEM encoded CA certificates, or comma separated paths to CA files or directories, used to verify Bindplane", "", &tls_ca_cert),
+		boolInput("tls_ca_append_system_pool", "Add tls_ca_cert to the system certificate pool instead of replacing it", false, &tls_ca_append_system_pool),
+		stringInput("github_url", "URL of the repository", "", &github_url),
+		stringInput("user_agent", "User agent of Bindplane requests", client.DefaultUserAgent, &user_agent),
+		stringInput("proxy_url", "Proxy URL used for outbound requests", "", &proxy_url),
+		stringInput("proxy_username", "Proxy username", "", &proxy_username),
+		stringInput("proxy_password", "Proxy password", "", &proxy_password),
+		stringInput("no_proxy", "Comma separated hosts which bypass the proxy", "", &no_proxy),
+		stringInput("targets_file", "Path to a file of Bindplane targets", "", &targets_file),
+		boolInput("enable_trace", "Trace Bindplane requests and responses", false, &enable_trace),
+		stringInput("trace_file", "Path to a file traces are appended to", "", &trace_file),
+		stringInput("operation", "Command to run when no command is given, such as plan or drift", "", &operation),
+		stringInput("log_level", "Minimum level of logs: debug, info, warn or error", defaultLogLevel, &log_level),
+		stringInput("log_format", "Format of logs: json, console or github", logging.FormatJSON, &log_format),
+		listInput("target_ref", "Comma separated patterns of branches or tags the action runs for, such as v*. Patterns starting with refs/ match the full ref", nil, &target_ref),
+		stringInput(branchEnvironmentsInput, "YAML mapping of branch patterns to the input values used for matching branches, such as the remote URL and API key of an environment", "", &branch_environments),
+		stringInput(configFileInput, "Path to a YAML file of input values, used for inputs not set by flags or environment variables. Defaults to "+defaultConfigFile+" when it exists", "", &config_file),
 	}
-	enable_otel_config_write_back = b
+}
 
-	configuration_output_dir = args[9]
-	token = args[10]
+func stringInput(name, usage, def string, dst *string) input {
+	return input{name: name, usage: usage, def: def, set: func(value string) error {
+		*dst = value
+		return nil
+	}}
+}
 
-	b, err = strconv.ParseBool(args[11])
-	if err != nil {
-		return fmt.Errorf("enable_auto_rollout must be a boolean value")
+func boolInput(name, usage string, def bool, dst *bool) input {
+	return input{name: name, usage: usage, def: strconv.FormatBool(def), set: func(value string) error {
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid value %q, must be a boolean value", value)
+		}
+		*dst = b
+		return nil
+	}}
+}
+
+func intInput(name, usage string, def int, dst *int) input {
+	return input{name: name, usage: usage, def: strconv.Itoa(def), set: func(value string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid value %q, must be an integer value", value)
+		}
+		*dst = n
+		return nil
+	}}
+}
+
+func durationInput(name, usage string, def time.Duration, dst *time.Duration) input {
+	return input{name: name, usage: usage, def: def.String(), set: func(value string) error {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid value %q, must be a duration such as 120s", value)
+		}
+		*dst = d
+		return nil
+	}}
+}
+
+// listInput parses a comma separated list, ignoring empty items
+func listInput(name, usage string, def []string, dst *[]string) input {
+	return input{name: name, usage: usage, def: strings.Join(def, ","), set: func(value string) error {
+		*dst = splitList(value)
+		return nil
+	}}
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// inputEnv returns the environment variable GitHub sets for the named input
+func inputEnv(name string) string {
+	return "INPUT_" + strings.ToUpper(strings.ReplaceAll(name, " ", "_"))
+}
+
+const (
+	// configFileInput is the input which names a file of input values
+	configFileInput = "config_file"
+
+	// defaultConfigFile is read when config_file is not set, if it exists
+	defaultConfigFile = ".bindplane-action.yaml"
+
+	// configFileBranches is the config file key of per-branch input values
+	configFileBranches = "branches"
+
+	// branchEnvironmentsInput is the input which maps branch
+	// patterns to input values, encoded as YAML
+	branchEnvironmentsInput = "branch_environments"
+
+	// bindplaneRemoteURLInput is the input which makes a config
+	// file branch an environment when set for the branch
+	bindplaneRemoteURLInput = "bindplane_remote_url"
+)
+
+// parseArgs sets the inputs of the action from the command line flags,
+// INPUT_<NAME> environment variables, branch_environments and the
+// config_file, in that order of precedence. Config file values for the
+// current branch take precedence over its top level values. Inputs which
+// are unset or empty use their default, and every invalid input is
+// reported. The remaining positional arguments are returned.
+func parseArgs(args []string) ([]string, error) {
+	ins := inputs()
+
+	fs := flag.NewFlagSet("bindplane-op-action", flag.ContinueOnError)
+	flags := make(map[string]*string, len(ins))
+	for _, in := range ins {
+		usage := fmt.Sprintf("%s (env %s)", in.usage, inputEnv(in.name))
+		flags[in.name] = fs.String(in.name, in.def, usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	// lookup returns the value of an input from its flag or environment variable
+	lookup := func(name string) string {
+		if set[name] {
+			return *flags[name]
+		}
+		return os.Getenv(inputEnv(name))
+	}
+
+	file := &configFile{}
+	if path := lookup(configFileInput); path != "" {
+		f, err := readConfigFile(path, ins)
+		if err != nil {
+			return nil, fmt.Errorf("input %s: %w", configFileInput, err)
+		}
+		file = f
+	} else if _, err := os.Stat(defaultConfigFile); err == nil {
+		f, err := readConfigFile(defaultConfigFile, ins)
+		if err != nil {
+			return nil, err
+		}
+		file = f
+	}
+
+	envs := branchInputs{}
+	if raw := lookup(branchEnvironmentsInput); raw != "" {
+		var v any
+		if err := yaml.Unmarshal([]byte(raw), &v); err != nil {
+			return nil, fmt.Errorf("input %s: parse: %w", branchEnvironmentsInput, err)
+		}
+		e, err := parseBranchInputs(v, branchKnownInputs(ins))
+		if err != nil {
+			return nil, fmt.Errorf("input %s: %w", branchEnvironmentsInput, err)
+		}
+		envs = e
+	}
+
+	current := currentRef()
+	envPattern, envMatched := envs.match(current.name)
+	filePattern, fileMatched := file.branches.match(current.name)
+
+	var errs []error
+	for _, in := range ins {
+		value, source := lookup(in.name), ""
+		if value == "" && envMatched {
+			value, source = envs[envPattern][in.name], fmt.Sprintf("%s.%s", branchEnvironmentsInput, envPattern)
+		}
+		if value == "" && fileMatched {
+			value, source = file.branches[filePattern][in.name], fmt.Sprintf("%s %s.%s", file.path, configFileBranches, filePattern)
+		}
+		if value == "" {
+			value, source = file.values[in.name], file.path
+		}
+		if value == "" {
+			value = in.def
+		}
+
+		if err := in.set(value); err != nil {
+			if source != "" {
+				err = fmt.Errorf("%s: %w", source, err)
+			}
+			errs = append(errs, fmt.Errorf("input %s: %w", in.name, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	// Branches matching an environment are target branches, and write
+	// back to themselves by default. Tags write back to target_branch.
+	// Config file branches are only environments when they set their
+	// own remote URL, otherwise they only override inputs.
+	environment_patterns = append(envs.patterns(), file.branches.environments().patterns()...)
+	slices.Sort(environment_patterns)
+	environment_patterns = slices.Compact(environment_patterns)
+	environment = ""
+	switch {
+	case envMatched:
+		environment = envPattern
+	case fileMatched && file.branches[filePattern][bindplaneRemoteURLInput] != "":
+		environment = filePattern
 	}
-	enable_auto_rollout = b
 
-	configuration_output_branch = args[12]
 	if configuration_output_branch == "" {
 		configuration_output_branch = target_branch
+		if environment != "" && !current.tag {
+			configuration_output_branch = current.name
+		}
+	}
+
+	return fs.Args(), nil
+}
+
+// branchInputs maps branch patterns to the input
+// values used when running for a matching branch
+type branchInputs map[string]map[string]string
+
+// match returns the pattern which best matches the branch or tag name.
+// See glob.MatchBest for how patterns are matched.
+func (b branchInputs) match(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	return glob.MatchBest(b.patterns(), name)
+}
+
+// patterns returns the sorted branch patterns
+func (b branchInputs) patterns() []string {
+	patterns := make([]string, 0, len(b))
+	for p := range b {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// environments returns the branches which set their own remote URL
+func (b branchInputs) environments() branchInputs {
+	envs := branchInputs{}
+	for p, values := range b {
+		if values[bindplaneRemoteURLInput] != "" {
+			envs[p] = values
+		}
+	}
+	return envs
+}
+
+// parseBranchInputs converts a YAML mapping of branch patterns to
+// mappings of input names to values
+func parseBranchInputs(v any, known map[string]bool) (branchInputs, error) {
+	branches := branchInputs{}
+	if v == nil {
+		return branches, nil
+	}
+
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("must map branch patterns to inputs")
+	}
+
+	for pattern, v := range m {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid branch pattern %q: %w", pattern, err)
+		}
+
+		inputs, ok := v.(map[string]any)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("%s: must map input names to values", pattern)
+		}
+
+		values, err := configFileValues(inputs, known)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+		branches[pattern] = values
 	}
 
-	tls_ca_cert = args[13]
-	if err := writeTLSFile("ca.crt", tls_ca_cert); err != nil {
-		return fmt.Errorf("failed to write TLS file: %w", err)
+	return branches, nil
+}
+
+// branchKnownInputs returns the inputs which can be set for a branch
+func branchKnownInputs(ins []input) map[string]bool {
+	known := make(map[string]bool, len(ins))
+	for _, in := range ins {
+		known[in.name] = in.name != configFileInput && in.name != branchEnvironmentsInput
 	}
+	return known
+}
 
-	source_path = args[14]
-	processor_path = args[15]
-	connector_path = args[16]
-	fleet_path = args[17]
-	github_url = args[18]
-	user_agent = args[19]
+// configFile holds the input values of a config file
+type configFile struct {
+	path string
 
-	return nil
+	// values maps input names to values
+	values map[string]string
+
+	// branches holds the input values for branches
+	branches branchInputs
 }
 
-// writeTLSFile takes a file path and writes the given contents to it
-func writeTLSFile(path string, contents string) error {
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 user defined filepath
+// readConfigFile reads a YAML file mapping input names to values. Values
+// for branches are set under the branches key, keyed by branch pattern. Lists are joined
+// with commas, as they are for list inputs.
+//
+//	bindplane_remote_url: https://bindplane.example.com
+//	configuration_path: resources/configurations
+//	branches:
+//	  main:
+//	    enable_auto_rollout: true
+func readConfigFile(path string, ins []input) (*configFile, error) {
+	b, err := os.ReadFile(path) // #nosec G304 user defined filepath
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	raw := map[string]any{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	known := branchKnownInputs(ins)
+
+	branches, err := parseBranchInputs(raw[configFileBranches], known)
 	if err != nil {
-		return fmt.Errorf("failed to create ca.crt file: %w", err)
+		return nil, fmt.Errorf("%s: %s: %w", path, configFileBranches, err)
 	}
-	defer f.Close()
+	delete(raw, configFileBranches)
 
-	_, err = f.WriteString(contents)
+	file := &configFile{path: path, branches: branches}
+
+	values, err := configFileValues(raw, known)
 	if err != nil {
-		return fmt.Errorf("failed to write to ca.crt file: %w", err)
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
+	file.values = values
+
+	return file, nil
+}
 
-	return nil
+// configFileValues converts a mapping of input names to YAML values
+// into input values
+func configFileValues(raw map[string]any, known map[string]bool) (map[string]string, error) {
+	values := make(map[string]string, len(raw))
+	for name, v := range raw {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown input %s", name)
+		}
+
+		switch t := v.(type) {
+		case nil:
+		case []any:
+			items := make([]string, 0, len(t))
+			for _, item := range t {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[name] = strings.Join(items, ",")
+		case map[string]any:
+			return nil, fmt.Errorf("input %s must be a value or list", name)
+		default:
+			values[name] = fmt.Sprint(t)
+		}
+	}
+	return values, nil
 }
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/stretchr/testify/require"
+)
+
+// resetInputs restores the global inputs to their zero values
+func resetInputs() {
+	bindplane_remote_url = ""
+	bindplane_api_key = ""
+	bindplane_username = ""
+	bindplane_password = ""
+	target_branch = ""
+	destination_path = ""
+	configuration_path = ""
+	enable_otel_config_write_back = false
+	configuration_output_dir = ""
+	token = ""
+	enable_auto_rollout = false
+	configuration_output_branch = ""
+	tls_ca_cert = ""
+	tls_ca_append_system_pool = false
+	tls_ca_certs = nil
+	source_path = ""
+	processor_path = ""
+	connector_path = ""
+	fleet_path = ""
+	github_url = ""
+	user_agent = ""
+	proxy_url = ""
+	proxy_username = ""
+	proxy_password = ""
+	no_proxy = ""
+	targets_file = ""
+	bindplane_oidc_token_url = ""
+	bindplane_oidc_audience = ""
+	enable_trace = false
+	trace_file = ""
+	operation = ""
+	log_level = ""
+	log_format = ""
+	write_back_mode = ""
+	github_api_url = ""
+	write_back_all_configurations = false
+	commit_author_name = ""
+	commit_author_email = ""
+	commit_message_template = ""
+	commit_signing_key = ""
+	commit_signing_key_passphrase = ""
+	configuration_output_format = ""
+	output_repository_url = ""
+	output_repository_token = ""
+	push_retries = 0
+	clone_depth = 0
+	clone_timeout = 0
+	validate_rendered_configs = false
+	redact_rendered_configs = false
+	redact_key_patterns = nil
+	config_file = ""
+	branch_environments = ""
+	target_ref = nil
+	environment_patterns = nil
+	environment = ""
+}
+
+func TestParseArgs(t *testing.T) {
+	cases := []struct {
+		name       string
+		env        map[string]string
+		args       []string
+		files      map[string]string
+		expectErr  string
+		expectArgs []string
+		check      func(t *testing.T)
+	}{
+		{
+			name: "Defaults",
+			env: map[string]string{
+				"INPUT_TARGET_BRANCH": "main",
+			},
+			check: func(t *testing.T) {
+				require.Equal(t, "main", target_branch)
+				require.Equal(t, "main", configuration_output_branch)
+				require.Equal(t, action.WriteBackModePush, write_back_mode)
+				require.Equal(t, action.OutputFormatRaw, configuration_output_format)
+				require.Equal(t, action.DefaultCommitAuthorName, commit_author_name)
+				require.Equal(t, action.DefaultCommitMessageTemplate, commit_message_template)
+				require.Equal(t, action.DefaultPushRetries, push_retries)
+				require.Equal(t, defaultCloneDepth, clone_depth)
+				require.Equal(t, 120*time.Second, clone_timeout)
+				require.Equal(t, action.DefaultRedactKeyPatterns, redact_key_patterns)
+				require.False(t, validate_rendered_configs)
+				require.False(t, enable_otel_config_write_back)
+			},
+		},
+		{
+			name: "Environment",
+			env: map[string]string{
+				"INPUT_BINDPLANE_REMOTE_URL":             "https://bindplane.example.com",
+				"INPUT_TARGET_BRANCH":                    "main",
+				"INPUT_CONFIGURATION_OUTPUT_BRANCH":      "configs",
+				"INPUT_ENABLE_OTEL_CONFIG_WRITE_BACK":    "true",
+				"INPUT_VALIDATE_RENDERED_CONFIGURATIONS": "true",
+				"INPUT_PUSH_RETRIES":                     "5",
+				"INPUT_CLONE_TIMEOUT":                    "30s",
+				"INPUT_REDACT_KEY_PATTERNS":              " *key* , ,*auth*",
+			},
+			check: func(t *testing.T) {
+				require.Equal(t, "https://bindplane.example.com", bindplane_remote_url)
+				require.Equal(t, "configs", configuration_output_branch)
+				require.True(t, enable_otel_config_write_back)
+				require.True(t, validate_rendered_configs)
+				require.Equal(t, 5, push_retries)
+				require.Equal(t, 30*time.Second, clone_timeout)
+				require.Equal(t, []string{"*key*", "*auth*"}, redact_key_patterns)
+			},
+		},
+		{
+			name: "TLS CA is not written to the working directory",
+			env: map[string]string{
+				"INPUT_TLS_CA_CERT":               "certs/ca.pem",
+				"INPUT_TLS_CA_APPEND_SYSTEM_POOL": "true",
+			},
+			check: func(t *testing.T) {
+				require.Equal(t, "certs/ca.pem", tls_ca_cert)
+				require.True(t, tls_ca_append_system_pool)
+				require.NoFileExists(t, "ca.crt")
+			},
+		},
+		{
+			name: "Flags override environment",
+			env: map[string]string{
+				"INPUT_TARGET_BRANCH": "main",
+				"INPUT_CLONE_DEPTH":   "10",
+			},
+			args: []string{"--target_branch", "release", "--clone_depth=0", "--enable_auto_rollout=true"},
+			check: func(t *testing.T) {
+				require.Equal(t, "release", target_branch)
+				require.Equal(t, 0, clone_depth)
+				require.True(t, enable_auto_rollout)
+			},
+		},
+		{
+			name: "Invalid values",
+			env: map[string]string{
+				"INPUT_ENABLE_TRACE":  "yes please",
+				"INPUT_PUSH_RETRIES":  "three",
+				"INPUT_CLONE_TIMEOUT": "2",
+			},
+			expectErr: "input clone_timeout: invalid value \"2\", must be a duration such as 120s\n" +
+				"input push_retries: invalid value \"three\", must be an integer value\n" +
+				"input enable_trace: invalid value \"yes please\", must be a boolean value",
+		},
+		{
+			name:      "Unknown flag",
+			args:      []string{"--not_an_input", "value"},
+			expectErr: "flag provided but not defined: -not_an_input",
+		},
+		{
+			name:       "Positional arguments",
+			args:       []string{"--target_branch", "main", "gateway", "agents"},
+			expectArgs: []string{"gateway", "agents"},
+			check: func(t *testing.T) {
+				require.Equal(t, "main", target_branch)
+			},
+		},
+		{
+			name: "Config file",
+			env: map[string]string{
+				"INPUT_CONFIG_FILE":   "inputs.yaml",
+				"INPUT_TARGET_BRANCH": "release",
+			},
+			args: []string{"--clone_depth", "5"},
+			files: map[string]string{
+				"inputs.yaml": "bindplane_remote_url: https://bindplane.example.com\n" +
+					"target_branch: main\n" +
+					"enable_auto_rollout: true\n" +
+					"clone_depth: 2\n" +
+					"push_retries: 7\n" +
+					"redact_key_patterns:\n  - '*key*'\n  - '*auth*'\n",
+			},
+			check: func(t *testing.T) {
+				require.Equal(t, "https://bindplane.example.com", bindplane_remote_url)
+				require.Equal(t, "release", target_branch)
+				require.True(t, enable_auto_rollout)
+				require.Equal(t, 5, clone_depth)
+				require.Equal(t, 7, push_retries)
+				require.Equal(t, []string{"*key*", "*auth*"}, redact_key_patterns)
+			},
+		},
+		{
+			name: "Config file unknown input",
+			args: []string{"--config_file", "inputs.yaml"},
+			files: map[string]string{
+				"inputs.yaml": "bindplane_url: https://bindplane.example.com\n",
+			},
+			expectErr: "input config_file: inputs.yaml: unknown input bindplane_url",
+		},
+		{
+			name: "Config file invalid value",
+			args: []string{"--config_file", "inputs.yaml"},
+			files: map[string]string{
+				"inputs.yaml": "clone_depth: shallow\n",
+			},
+			expectErr: "input clone_depth: inputs.yaml: invalid value \"shallow\", must be an integer value",
+		},
+		{
+			name: "Default config file",
+			files: map[string]string{
+				defaultConfigFile: "target_branch: main\nfleet_path: fleets\n",
+			},
+			check: func(t *testing.T) {
+				require.Equal(t, "main", target_branch)
+				require.Equal(t, "fleets", fleet_path)
+			},
+		},
+		{
+			name: "Default config file invalid",
+			files: map[string]string{
+				defaultConfigFile: "branches: [main]\n",
+			},
+			expectErr: ".bindplane-action.yaml: branches: must map branch patterns to inputs",
+		},
+		{
+			name: "Branch overrides",
+			env: map[string]string{
+				"GITHUB_ACTIONS":     "true",
+				"GITHUB_REF":         "refs/heads/main",
+				"INPUT_PUSH_RETRIES": "1",
+			},
+			files: map[string]string{
+				defaultConfigFile: "bindplane_remote_url: https://staging.example.com\n" +
+					"target_branch: main\n" +
+					"push_retries: 5\n" +
+					"branches:\n" +
+					"  main:\n" +
+					"    bindplane_remote_url: https://production.example.com\n" +
+					"    enable_auto_rollout: true\n" +
+					"    push_retries: 9\n" +
+					"  develop:\n" +
+					"    bindplane_remote_url: https://develop.example.com\n",
+			},
+			check: func(t *testing.T) {
+				require.Equal(t, "https://production.example.com", bindplane_remote_url)
+				require.True(t, enable_auto_rollout)
+				require.Equal(t, 1, push_retries, "explicit inputs take precedence")
+			},
+		},
+		{
+			name: "Branch override invalid",
+			env: map[string]string{
+				"GITHUB_ACTIONS": "true",
+				"GITHUB_REF":     "refs/heads/main",
+			},
+			files: map[string]string{
+				defaultConfigFile: "branches:\n  main:\n    enable_trace: sometimes\n",
+			},
+			expectErr: "input enable_trace: .bindplane-action.yaml branches.main: invalid value \"sometimes\", must be a boolean value",
+		},
+		{
+			name: "Branch override unknown input",
+			files: map[string]string{
+				defaultConfigFile: "branches:\n  main:\n    remote_url: https://production.example.com\n",
+			},
+			expectErr: ".bindplane-action.yaml: branches: main: unknown input remote_url",
+		},
+		{
+			name: "Branch environments",
+			env: map[string]string{
+				"GITHUB_ACTIONS": "true",
+				"GITHUB_REF":     "refs/heads/staging-eu",
+				"INPUT_BRANCH_ENVIRONMENTS": "main:\n" +
+					"  bindplane_remote_url: https://bindplane.example.com\n" +
+					"  bindplane_api_key: production\n" +
+					"staging-*:\n" +
+					"  bindplane_remote_url: https://staging.example.com\n" +
+					"  bindplane_api_key: staging\n",
+			},
+			files: map[string]string{
+				defaultConfigFile: "target_branch: main\n" +
+					"branches:\n" +
+					"  staging-*:\n" +
+					"    bindplane_remote_url: https://ignored.example.com\n" +
+					"    enable_auto_rollout: true\n",
+			},
+			check: func(t *testing.T) {
+				require.Equal(t, "https://staging.example.com", bindplane_remote_url)
+				require.Equal(t, "staging", bindplane_api_key)
+				require.True(t, enable_auto_rollout, "config file branch values are used when unset by the environment")
+				require.Equal(t, "staging-*", environment)
+				require.Equal(t, []string{"main", "staging-*"}, environment_patterns)
+				require.Equal(t, "staging-eu", configuration_output_branch)
+				require.True(t, isTargetRef(branchRef("staging-eu")))
+			},
+		},
+		{
+			name: "Branch environments tag",
+			env: map[string]string{
+				"GITHUB_ACTIONS":            "true",
+				"GITHUB_REF":                "refs/tags/v1.2.3",
+				"INPUT_TARGET_BRANCH":       "main",
+				"INPUT_BRANCH_ENVIRONMENTS": "v*:\n  bindplane_api_key: production\n",
+			},
+			check: func(t *testing.T) {
+				require.Equal(t, "production", bindplane_api_key)
+				require.Equal(t, "v*", environment)
+				require.Equal(t, "main", configuration_output_branch, "tags write back to target_branch")
+			},
+		},
+		{
+			name: "Branch environments slash",
+			env: map[string]string{
+				"GITHUB_ACTIONS":            "true",
+				"GITHUB_REF":                "refs/heads/release/1.0",
+				"INPUT_BRANCH_ENVIRONMENTS": "release:\n  bindplane_api_key: wrong\nrelease/*:\n  bindplane_api_key: release\n",
+			},
+			check: func(t *testing.T) {
+				require.Equal(t, "release", bindplane_api_key)
+				require.Equal(t, "release/1.0", configuration_output_branch)
+			},
+		},
+		{
+			name: "Branch environments no match",
+			env: map[string]string{
+				"GITHUB_ACTIONS":            "true",
+				"GITHUB_REF":                "refs/heads/feature",
+				"INPUT_TARGET_BRANCH":       "main",
+				"INPUT_BRANCH_ENVIRONMENTS": "main:\n  bindplane_api_key: production\n",
+			},
+			check: func(t *testing.T) {
+				require.Empty(t, bindplane_api_key)
+				require.Empty(t, environment)
+				require.Equal(t, "main", configuration_output_branch)
+				require.False(t, isTargetRef(branchRef("feature")))
+				require.True(t, isTargetRef(branchRef("main")))
+			},
+		},
+		{
+			name: "Config file branch override",
+			env: map[string]string{
+				"GITHUB_ACTIONS": "true",
+				"GITHUB_REF":     "refs/heads/develop",
+			},
+			files: map[string]string{
+				defaultConfigFile: "target_branch: main\n" +
+					"branches:\n" +
+					"  develop:\n" +
+					"    enable_auto_rollout: true\n",
+			},
+			check: func(t *testing.T) {
+				require.True(t, enable_auto_rollout)
+				require.Empty(t, environment, "branches without a remote URL only override inputs")
+				require.Empty(t, environment_patterns)
+				require.Equal(t, "main", configuration_output_branch)
+				require.False(t, isTargetRef(branchRef("develop")))
+			},
+		},
+		{
+			name: "Branch environments invalid pattern",
+			env: map[string]string{
+				"INPUT_BRANCH_ENVIRONMENTS": "'release/[':\n  bindplane_api_key: release\n",
+			},
+			expectErr: "input branch_environments: invalid branch pattern \"release/[\"",
+		},
+		{
+			name: "Branch environments nested",
+			env: map[string]string{
+				"INPUT_BRANCH_ENVIRONMENTS": "main:\n  branch_environments: nested\n",
+			},
+			expectErr: "input branch_environments: main: unknown input branch_environments",
+		},
+		{
+			name:      "Config file missing",
+			args:      []string{"--config_file", "inputs.yaml"},
+			expectErr: "input config_file: read inputs.yaml",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// parseArgs reads .bindplane-action.yaml from the working directory
+			t.Chdir(t.TempDir())
+			for _, in := range inputs() {
+				t.Setenv(inputEnv(in.name), "")
+			}
+			for _, env := range []string{"GITHUB_ACTIONS", "GITHUB_REF", "GITHUB_HEAD_REF"} {
+				t.Setenv(env, "")
+			}
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			for name, contents := range tc.files {
+				require.NoError(t, os.WriteFile(name, []byte(contents), 0600))
+			}
+			defer resetInputs()
+
+			args, err := parseArgs(tc.args)
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.expectArgs, args)
+			tc.check(t)
+		})
+	}
+}
+
+func TestInputEnv(t *testing.T) {
+	require.Equal(t, "INPUT_BINDPLANE_REMOTE_URL", inputEnv("bindplane_remote_url"))
+	require.Equal(t, "INPUT_MY_INPUT", inputEnv("my input"))
+}
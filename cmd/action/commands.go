@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"go.uber.org/zap"
+)
+
+const (
+	commandRun       = "run"
+	commandApply     = "apply"
+	commandPlan      = "plan"
+	commandRollout   = "rollout"
+	commandWriteBack = "writeback"
+	commandValidate  = "validate"
+	commandExport    = "export"
+	commandDrift     = "drift"
+)
+
+// command is a subcommand of the action binary
+type command struct {
+	name        string
+	description string
+
+	// args describes the positional arguments of the command.
+	// Commands without args do not accept positional arguments.
+	args string
+
+	// mutates is true for commands which change BindPlane or the
+	// repository. In GitHub Actions, they only run for target refs.
+	mutates bool
+
+	// run executes the command and returns the exit code
+	run func(logger *zap.Logger, opts []action.Option, args []string) int
+}
+
+// commands are the subcommands of the action binary. The first is used
+// when a command is not given and the operation input is not set.
+var commands = []command{
+	{
+		name:        commandRun,
+		description: "Apply resources, then roll out and write back configurations when enabled",
+		mutates:     true,
+		run:         runWorkflow,
+	},
+	{
+		name:        commandApply,
+		description: "Apply resources, then roll out configurations when enabled",
+		mutates:     true,
+		run:         runApply,
+	},
+	{
+		name:        commandPlan,
+		description: "Show the changes applying resources would make, without applying them",
+		run:         runPlan,
+	},
+	{
+		name:        commandRollout,
+		description: "Start rollouts for the named configurations, or for pending configurations in configuration_path",
+		args:        "[configuration...]",
+		mutates:     true,
+		run:         runRollout,
+	},
+	{
+		name:        commandWriteBack,
+		description: "Write back the rendered configurations of resources, without applying them",
+		mutates:     true,
+		run:         runWriteBack,
+	},
+	{
+		name:        commandExport,
+		description: "Write the resources in Bindplane to the resource paths",
+		mutates:     true,
+		run:         runExport,
+	},
+	{
+		name:        commandDrift,
+		description: "Report resources which differ between Bindplane and the resource paths",
+		run:         runDrift,
+	},
+	{
+		name:        commandValidate,
+		description: "Validate inputs and resource files, without connecting to Bindplane",
+		run:         runValidate,
+	},
+}
+
+// splitCommand returns the command named by the first argument and the
+// remaining arguments. The command is empty when the first argument is a
+// flag or there are no arguments. See commandName for the command run then.
+func splitCommand(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", args
+	}
+	return args[0], args[1:]
+}
+
+// findCommand returns the command with the given name
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// printCommands writes the list of commands to w
+func printCommands(w io.Writer) {
+	fmt.Fprintf(w, "\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.description)
+		if c.args != "" {
+			fmt.Fprintf(w, "  %-10s arguments: %s\n", "", c.args)
+		}
+	}
+}
+
+// inActions returns true when running in a GitHub Actions runner
+func inActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// runWorkflow runs the full workflow. When running in GitHub Actions,
+// a commit message containing `progress rollout <name>` progresses the
+// rollout for the configuration instead.
+func runWorkflow(logger *zap.Logger, opts []action.Option, _ []string) int {
+	rolloutName := ""
+	if inActions() {
+		r := githubRef()
+		switch {
+		case r.tag:
+			logger.Info("Skipping commit message check, running for a tag", zap.String("tag", r.name))
+		case token != "" || github_url != "":
+			// Retrieve the commit message from the head commit on the branch
+			message, err := commitMessage(github_url, r.branch(), token)
+			if err != nil {
+				logger.Error("error getting commit message", zap.Error(err))
+				return action.ExitCodeError
+			}
+			rolloutName, _ = extractConfigName(message)
+		default:
+			logger.Info("Skipping commit message check, Github token not provided")
+		}
+	}
+
+	return runTargets(logger, opts, true, func(a *action.Action) error {
+		if rolloutName != "" {
+			if err := a.RunRollout(rolloutName); err != nil {
+				return fmt.Errorf("progress rollout: %w", err)
+			}
+			return nil
+		}
+		return a.Run()
+	})
+}
+
+// runApply applies resources without writing back configurations
+func runApply(logger *zap.Logger, opts []action.Option, _ []string) int {
+	opts = append(opts, action.WithOTELConfigWriteBack(false))
+	return runTargets(logger, opts, true, func(a *action.Action) error {
+		return a.Run()
+	})
+}
+
+// runPlan logs the change applying each resource would make, and adds
+// them to the job summary when running in GitHub Actions
+func runPlan(logger *zap.Logger, opts []action.Option, _ []string) int {
+	return runTargets(logger, opts, true, func(a *action.Action) error {
+		changes, err := a.Plan()
+		if err != nil {
+			return fmt.Errorf("plan: %w", err)
+		}
+
+		counts := map[model.UpdateStatus]int{}
+		b := strings.Builder{}
+		b.WriteString("### Bindplane plan\n\n")
+		b.WriteString("| Kind | Name | Status | Path |\n")
+		b.WriteString("| :--- | :--- | :----- | :--- |\n")
+		for _, c := range changes {
+			counts[c.Status]++
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", c.Kind, markdownCell(c.Name), c.Status, markdownCell(c.Path))
+		}
+
+		logger.Info("Plan complete",
+			zap.Int(string(model.StatusCreated), counts[model.StatusCreated]),
+			zap.Int(string(model.StatusConfigured), counts[model.StatusConfigured]),
+			zap.Int(string(model.StatusUnchanged), counts[model.StatusUnchanged]),
+		)
+
+		if err := appendRunnerFile("GITHUB_STEP_SUMMARY", b.String()); err != nil {
+			logger.Warn("Failed to write job summary", zap.Error(err))
+		}
+		return nil
+	})
+}
+
+// runRollout starts rollouts for the named configurations. Without names,
+// rollouts are started for configurations in configuration_path which
+// have a pending rollout.
+func runRollout(logger *zap.Logger, opts []action.Option, names []string) int {
+	if len(names) == 0 {
+		opts = append(opts, action.WithAutoRollout(true))
+	}
+
+	return runTargets(logger, opts, true, func(a *action.Action) error {
+		if len(names) == 0 {
+			if err := a.CheckCompatibility(); err != nil {
+				return fmt.Errorf("incompatible BindPlane version: %w", err)
+			}
+			if err := a.Load(); err != nil {
+				return fmt.Errorf("load resources: %w", err)
+			}
+			return a.AutoRollout()
+		}
+
+		for _, name := range names {
+			logger.Info("Starting rollout", zap.String("name", name))
+			if err := a.RunRollout(name); err != nil {
+				return fmt.Errorf("rollout %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// runWriteBack writes back the rendered configurations of the resources
+// in the resource paths, which must already be applied
+func runWriteBack(logger *zap.Logger, opts []action.Option, _ []string) int {
+	return runTargets(logger, opts, true, func(a *action.Action) error {
+		if err := a.Load(); err != nil {
+			return fmt.Errorf("load resources: %w", err)
+		}
+		return a.WriteBack()
+	})
+}
+
+// runExport writes the resources in Bindplane to the resource paths
+func runExport(logger *zap.Logger, opts []action.Option, _ []string) int {
+	return runTargets(logger, opts, true, func(a *action.Action) error {
+		files, err := a.Export()
+		if err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+		logger.Info("Export complete", zap.Strings("files", files))
+		return nil
+	})
+}
+
+// runDrift reports the resources which differ between Bindplane and the
+// resource paths, and adds them to the job summary when running in GitHub
+// Actions. The drift_detected output is set, and the command fails with
+// action.ExitCodeDrift when drift is found.
+func runDrift(logger *zap.Logger, opts []action.Option, _ []string) int {
+	detected := false
+	code := runTargets(logger, opts, true, func(a *action.Action) error {
+		drift, err := a.Drift()
+		if len(drift) == 0 {
+			if err == nil {
+				logger.Info("No drift detected")
+			}
+			return err
+		}
+		detected = true
+
+		b := strings.Builder{}
+		b.WriteString("### Bindplane drift\n\n")
+		b.WriteString("| Kind | Name | Reason | Path |\n")
+		b.WriteString("| :--- | :--- | :----- | :--- |\n")
+		for _, d := range drift {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", d.Kind, markdownCell(d.Name), d.Reason, markdownCell(d.Path))
+		}
+		if err := appendRunnerFile("GITHUB_STEP_SUMMARY", b.String()); err != nil {
+			logger.Warn("Failed to write job summary", zap.Error(err))
+		}
+		return err
+	})
+
+	if err := setOutput("drift_detected", strconv.FormatBool(detected)); err != nil {
+		logger.Warn("Failed to set drift_detected output", zap.Error(err))
+	}
+	return code
+}
+
+// runValidate decodes the resource files. Inputs have already been
+// validated by the time a command runs.
+func runValidate(logger *zap.Logger, opts []action.Option, _ []string) int {
+	code := runTargets(logger, opts, false, func(a *action.Action) error {
+		if err := a.Load(); err != nil {
+			return fmt.Errorf("load resources: %w", err)
+		}
+		return nil
+	})
+	if code == 0 {
+		logger.Info("Inputs and resource files are valid")
+	}
+	return code
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSplitCommand(t *testing.T) {
+	cases := []struct {
+		name        string
+		args        []string
+		expectName  string
+		expectArgs  []string
+		expectFound bool
+	}{
+		{"No arguments", nil, "", nil, false},
+		{"Flags only", []string{"--target_branch", "main"}, "", []string{"--target_branch", "main"}, false},
+		{"Command", []string{"plan", "--target_branch", "main"}, commandPlan, []string{"--target_branch", "main"}, true},
+		{"Command with arguments", []string{"rollout", "gateway"}, commandRollout, []string{"gateway"}, true},
+		{"Unknown command", []string{"deploy"}, "deploy", []string{}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, args := splitCommand(tc.args)
+			require.Equal(t, tc.expectName, name)
+			require.ElementsMatch(t, tc.expectArgs, args)
+
+			_, found := findCommand(name)
+			require.Equal(t, tc.expectFound, found)
+		})
+	}
+}
+
+func TestRunValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte("kind: Destination\nmetadata:\n  name: gateway\nspec:\n  type: logging\n"), 0600))
+	require.NoError(t, os.WriteFile(invalid, []byte("kind: [Destination\n"), 0600))
+
+	// Validation does not connect to BindPlane, so the URL is unreachable
+	opts := []action.Option{action.WithBindPlaneRemoteURL("http://127.0.0.1:1")}
+
+	code := runValidate(zap.NewNop(), append(opts, action.WithDestinationPath(valid)), nil)
+	require.Equal(t, 0, code)
+
+	code = runValidate(zap.NewNop(), append(opts, action.WithDestinationPath(invalid)), nil)
+	require.Equal(t, action.ExitCodeInvalidResource, code)
+}
+
+func TestValidateOutsideActions(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	bindplane_remote_url = "http://localhost:3001"
+	bindplane_api_key = "key"
+	clone_timeout = time.Minute
+	defer resetInputs()
+
+	// target_branch and the runner environment are only required in GitHub Actions
+	require.NoError(t, validate())
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	require.ErrorContains(t, validate(), "target_branch is required")
+}
+
+func TestRunSkipsOtherRefs(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	for _, op := range []string{commandRun, commandApply, commandRollout, commandWriteBack, commandExport} {
+		t.Run(op, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			t.Setenv("GITHUB_ACTIONS", "true")
+			t.Setenv("GITHUB_REF", "refs/heads/feature")
+			t.Setenv("GITHUB_HEAD_REF", "")
+			t.Setenv("GITHUB_EVENT_NAME", "")
+			t.Setenv("INPUT_OPERATION", op)
+			t.Setenv("INPUT_TARGET_BRANCH", "main")
+			t.Setenv("INPUT_BINDPLANE_REMOTE_URL", server.URL)
+			t.Setenv("INPUT_BINDPLANE_API_KEY", "key")
+			defer resetInputs()
+
+			require.Equal(t, 0, run(nil))
+			require.Zero(t, requests.Load(), "the client is not used for refs which are not targets")
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	eventWorkflowDispatch   = "workflow_dispatch"
+	eventRepositoryDispatch = "repository_dispatch"
+)
+
+// dispatch holds the parameters of a workflow_dispatch or
+// repository_dispatch event
+type dispatch struct {
+	// operation is the name of the command to run
+	operation string
+
+	// configurations are the arguments of the rollout command
+	configurations []string
+}
+
+// readDispatch reads the parameters of a dispatch event from the event
+// payload at GITHUB_EVENT_PATH. workflow_dispatch events hold them in
+// their inputs, and repository_dispatch events in their client_payload.
+// Only operation and configurations are read, so a dispatch cannot change
+// where the action connects or the credentials it uses.
+func readDispatch() (dispatch, error) {
+	if !inActions() {
+		return dispatch{}, nil
+	}
+
+	field := ""
+	switch os.Getenv("GITHUB_EVENT_NAME") {
+	case eventWorkflowDispatch:
+		field = "inputs"
+	case eventRepositoryDispatch:
+		field = "client_payload"
+	default:
+		return dispatch{}, nil
+	}
+
+	path := os.Getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return dispatch{}, nil
+	}
+
+	b, err := os.ReadFile(path) // #nosec G304 path is set by the runner
+	if err != nil {
+		return dispatch{}, fmt.Errorf("read event payload: %w", err)
+	}
+
+	payload := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return dispatch{}, fmt.Errorf("decode event payload: %w", err)
+	}
+
+	params := map[string]any{}
+	if raw, ok := payload[field]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return dispatch{}, fmt.Errorf("decode event payload %s: %w", field, err)
+		}
+	}
+
+	d := dispatch{}
+	switch v := params["operation"].(type) {
+	case nil:
+	case string:
+		d.operation = strings.TrimSpace(v)
+	default:
+		return dispatch{}, fmt.Errorf("event payload %s.operation must be a string", field)
+	}
+
+	switch v := params["configurations"].(type) {
+	case nil:
+	case string:
+		d.configurations = splitList(v)
+	case []any:
+		for _, c := range v {
+			name, ok := c.(string)
+			if !ok {
+				return dispatch{}, fmt.Errorf("event payload %s.configurations must be a list of names", field)
+			}
+			if name = strings.TrimSpace(name); name != "" {
+				d.configurations = append(d.configurations, name)
+			}
+		}
+	default:
+		return dispatch{}, fmt.Errorf("event payload %s.configurations must be a list of names", field)
+	}
+
+	return d, nil
+}
+
+// commandName returns the name of the command to run. A command given on
+// the command line takes precedence over the operation input, which takes
+// precedence over the operation of a dispatch event.
+func commandName(arg string, d dispatch) (string, error) {
+	names := make([]string, 0, len(commands))
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+
+	switch {
+	case arg != "":
+		return arg, nil
+	case operation != "":
+		if !slices.Contains(names, operation) {
+			return "", fmt.Errorf("operation must be one of %s", strings.Join(names, ", "))
+		}
+		return operation, nil
+	case d.operation != "":
+		if !slices.Contains(names, d.operation) {
+			return "", fmt.Errorf("event payload operation must be one of %s", strings.Join(names, ", "))
+		}
+		return d.operation, nil
+	default:
+		return commands[0].name, nil
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDispatch(t *testing.T) {
+	cases := []struct {
+		name      string
+		actions   string
+		event     string
+		payload   string
+		expect    dispatch
+		expectErr string
+	}{
+		{
+			name:    "Outside of actions",
+			actions: "",
+			event:   eventWorkflowDispatch,
+			payload: `{"inputs": {"operation": "plan"}}`,
+			expect:  dispatch{},
+		},
+		{
+			name:    "Other event",
+			actions: "true",
+			event:   "push",
+			payload: `{"inputs": {"operation": "plan"}}`,
+			expect:  dispatch{},
+		},
+		{
+			name:    "Workflow dispatch",
+			actions: "true",
+			event:   eventWorkflowDispatch,
+			payload: `{"inputs": {"operation": " rollout ", "configurations": "agents, ,gateway", "bindplane_remote_url": "https://evil.example.com"}}`,
+			expect:  dispatch{operation: "rollout", configurations: []string{"agents", "gateway"}},
+		},
+		{
+			name:    "Workflow dispatch without inputs",
+			actions: "true",
+			event:   eventWorkflowDispatch,
+			payload: `{"inputs": null}`,
+			expect:  dispatch{},
+		},
+		{
+			name:    "Repository dispatch",
+			actions: "true",
+			event:   eventRepositoryDispatch,
+			payload: `{"action": "bindplane", "client_payload": {"operation": "rollout", "configurations": ["agents", "gateway"]}}`,
+			expect:  dispatch{operation: "rollout", configurations: []string{"agents", "gateway"}},
+		},
+		{
+			name:      "Invalid operation",
+			actions:   "true",
+			event:     eventRepositoryDispatch,
+			payload:   `{"client_payload": {"operation": 1}}`,
+			expectErr: "event payload client_payload.operation must be a string",
+		},
+		{
+			name:      "Invalid configurations",
+			actions:   "true",
+			event:     eventRepositoryDispatch,
+			payload:   `{"client_payload": {"configurations": [1]}}`,
+			expectErr: "event payload client_payload.configurations must be a list of names",
+		},
+		{
+			name:      "Malformed payload",
+			actions:   "true",
+			event:     eventWorkflowDispatch,
+			payload:   `{`,
+			expectErr: "decode event payload",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "event.json")
+			require.NoError(t, os.WriteFile(path, []byte(tc.payload), 0600))
+			t.Setenv("GITHUB_ACTIONS", tc.actions)
+			t.Setenv("GITHUB_EVENT_NAME", tc.event)
+			t.Setenv("GITHUB_EVENT_PATH", path)
+
+			d, err := readDispatch()
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, d)
+		})
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	cases := []struct {
+		name      string
+		arg       string
+		operation string
+		dispatch  dispatch
+		expect    string
+		expectErr string
+	}{
+		{name: "Default", expect: commandRun},
+		{name: "Command argument", arg: commandPlan, operation: commandDrift, dispatch: dispatch{operation: commandExport}, expect: commandPlan},
+		{name: "Operation input", operation: commandDrift, dispatch: dispatch{operation: commandExport}, expect: commandDrift},
+		{name: "Dispatch operation", dispatch: dispatch{operation: commandExport}, expect: commandExport},
+		{name: "Invalid operation input", operation: "deploy", expectErr: "operation must be one of run, apply, plan, rollout, writeback, export, drift, validate"},
+		{name: "Invalid dispatch operation", dispatch: dispatch{operation: "deploy"}, expectErr: "event payload operation must be one of"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			operation = tc.operation
+			defer func() { operation = "" }()
+
+			name, err := commandName(tc.arg, tc.dispatch)
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, name)
+		})
+	}
+}
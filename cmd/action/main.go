@@ -1,25 +1,25 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/observiq/bindplane-op-action/action"
+	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"github.com/observiq/bindplane-op-action/internal/logging"
 	"github.com/observiq/bindplane-op-action/internal/repo"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-// argCount is the number of arguments passed to the action, and does not
-// include the binary name itself (which is returned by os.Args[0]).
-// When adding new arguments to the action, this number should be updated
-// and new global variables should be declared and handled in parseArgs().
-const argCount = 19
-
 // Global variables will be used when creating the action configuration. These
-// are the options set by the user. Their order in parseArgs() is important.
+// are the options set by the user, and are set from the inputs in parseArgs().
 var (
 	bindplane_remote_url          string
 	bindplane_api_key             string
@@ -34,76 +34,175 @@ var (
 	enable_auto_rollout           bool
 	configuration_output_branch   string
 	tls_ca_cert                   string
+	tls_ca_append_system_pool     bool
 	source_path                   string
 	processor_path                string
 	connector_path                string
 	fleet_path                    string
 	github_url                    string
 	user_agent                    string
+	proxy_url                     string
+	proxy_username                string
+	proxy_password                string
+	no_proxy                      string
+	targets_file                  string
+	bindplane_oidc_token_url      string
+	bindplane_oidc_audience       string
+	enable_trace                  bool
+	trace_file                    string
+	operation                     string
+	log_level                     string
+	log_format                    string
+	write_back_mode               string
+	github_api_url                string
+	write_back_all_configurations bool
+	commit_author_name            string
+	commit_author_email           string
+	commit_message_template       string
+	commit_signing_key            string
+	commit_signing_key_passphrase string
+	configuration_output_format   string
+	output_repository_url         string
+	output_repository_token       string
+	push_retries                  int
+	clone_depth                   int
+	clone_timeout                 time.Duration
+	validate_rendered_configs     bool
+	redact_rendered_configs       bool
+	redact_key_patterns           []string
+	config_file                   string
+	branch_environments           string
+	target_ref                    []string
 )
 
-const (
-	exitParseArgsError            = 100
-	exitValidationError           = 101
-	exitClientInitError           = 102
-	exitClientTestConnectionError = 103
-	exitLoggerInitError           = 104
-	exitClientError               = 1
+// environment_patterns are the branch patterns of branch_environments and
+// the config file. environment is the pattern matching the current branch
+// or tag.
+var (
+	environment_patterns []string
+	environment          string
 )
 
+// targets are loaded from targets_file during validation. When empty, the
+// action runs once using the top level options.
+var targets []action.Target
+
+// tls_ca_certs are the certificate authorities loaded from tls_ca_cert
+// during validation
+var tls_ca_certs []string
+
 func main() {
-	if err := parseArgs(); err != nil {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command named by the arguments and returns the exit code
+func run(argv []string) int {
+	arg, args := splitCommand(argv)
+
+	args, err := parseArgs(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommands(os.Stderr)
+			return 0
+		}
 		fmt.Printf("Error parsing arguments: %s\n", err)
-		os.Exit(exitParseArgsError)
+		return action.ExitCodeParseArgs
 	}
 
-	if err := validate(); err != nil {
-		fmt.Printf("Error validating arguments: %s\n", err)
-		os.Exit(exitValidationError)
-	}
-
-	zapConf := zap.NewProductionConfig()
-	zapConf.Level.SetLevel(zap.DebugLevel) // TODO(jsirianni): Expose this as an option
-	zapConf.OutputPaths = []string{"stdout"}
-	zapConf.DisableStacktrace = true
-	zapConf.DisableCaller = true
-	zapConf.EncoderConfig.TimeKey = "time"
-	zapConf.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	logger, err := zapConf.Build()
+	// Workflows started from the Actions UI or the API can
+	// choose the operation and the configurations to roll out
+	d, err := readDispatch()
+	if err != nil {
+		fmt.Printf("Error parsing arguments: %s\n", err)
+		return action.ExitCodeParseArgs
+	}
+
+	name, err := commandName(arg, d)
+	if err != nil {
+		fmt.Printf("Error parsing arguments: %s\n", err)
+		return action.ExitCodeParseArgs
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Printf("Error parsing arguments: unknown command %q\n", name)
+		printCommands(os.Stdout)
+		return action.ExitCodeParseArgs
+	}
+
+	if len(args) == 0 && cmd.args != "" {
+		args = d.configurations
+	}
+	if len(args) > 0 && cmd.args == "" {
+		fmt.Printf("Error parsing arguments: %s does not accept arguments, got %q\n", cmd.name, args)
+		return action.ExitCodeParseArgs
+	}
+
+	// The writeback command always writes back
+	if cmd.name == commandWriteBack {
+		enable_otel_config_write_back = true
+	}
+
+	// Secrets are masked before anything is logged
+	masker = newMasker()
+
+	logger, err := logging.New(log_level, log_format, masker.Writer(zapcore.Lock(os.Stdout)))
 	if err != nil {
 		fmt.Printf("failed to create logger: %s\n", err)
-		os.Exit(exitLoggerInitError)
+		return action.ExitCodeLoggerInit
 	}
 
-	// Get the current branch from GITHUB_HEAD_REF or GITHUB_REF
-	currentBranch := os.Getenv("GITHUB_HEAD_REF")
-	if currentBranch == "" {
-		// Fallback to extracting from GITHUB_REF for non-PR contexts
-		refParts := strings.Split(os.Getenv("GITHUB_REF"), "/")
-		if len(refParts) >= 3 {
-			currentBranch = refParts[2]
+	// In GitHub Actions, commands which change BindPlane or the repository
+	// only run for target branches, whether they are the default command or
+	// chosen by the operation input or a dispatch event. This is checked
+	// before validation because inputs may only be set for the branches of
+	// environments.
+	if cmd.mutates && inActions() {
+		if r := githubRef(); !isTargetRef(r) {
+			logger.Info(
+				"Skipping action, ref does not match target branch, target ref or environments",
+				zap.String("ref", r.full),
+				zap.String("target_branch", target_branch),
+				zap.Strings("target_ref", target_ref),
+				zap.Strings("environments", environment_patterns),
+			)
+			return 0
 		}
 	}
 
-	if currentBranch != target_branch {
-		logger.Info(
-			"Skipping action, branch does not match target branch",
-			zap.String("branch", currentBranch),
-			zap.String("target_branch", target_branch),
-		)
-		os.Exit(0)
+	if environment != "" {
+		logger.Info("Using environment for branch", zap.String("environment", environment))
 	}
 
-	action, err := action.New(
-		logger,
+	if err := validate(); err != nil {
+		logger.Error("Error validating arguments", zap.Error(err))
+		return action.ExitCodeValidation
+	}
+	masker.Add(targetSecrets(targets)...)
+
+	return cmd.run(logger, baseOptions(), args)
+}
 
+// baseOptions returns the action options set by the inputs
+func baseOptions() []action.Option {
+	return []action.Option{
 		// Client options
 		action.WithBindPlaneRemoteURL(bindplane_remote_url),
 		action.WithBindPlaneAPIKey(bindplane_api_key),
 		action.WithBindPlaneUsername(bindplane_username),
 		action.WithBindPlanePassword(bindplane_password),
-		action.WithTLSCACert(tls_ca_cert),
+		action.WithBindPlaneOIDCTokenURL(bindplane_oidc_token_url),
+		action.WithBindPlaneOIDCAudience(bindplane_oidc_audience),
+		action.WithTLSCACerts(tls_ca_certs),
+		action.WithTLSAppendSystemCertPool(tls_ca_append_system_pool),
+		action.WithMasker(masker),
 		action.WithUserAgent(user_agent),
+		action.WithProxyURL(proxy_url),
+		action.WithProxyUsername(proxy_username),
+		action.WithProxyPassword(proxy_password),
+		action.WithNoProxy(no_proxy),
+		action.WithTrace(enable_trace),
+		action.WithTraceFile(trace_file),
 
 		// Base action options for reading resources
 		// from the repo, to apply to bindplane
@@ -121,63 +220,142 @@ func main() {
 		action.WithOTELConfigWriteBack(enable_otel_config_write_back),
 		action.WithConfigurationOutputDir(configuration_output_dir),
 		action.WithConfigurationOutputBranch(configuration_output_branch),
+		action.WithConfigurationOutputFormat(configuration_output_format),
+		action.WithValidateRenderedConfigurations(validate_rendered_configs),
+		action.WithRedactRenderedConfigurations(redact_rendered_configs),
+		action.WithRedactKeyPatterns(redact_key_patterns),
 		action.WithGithubToken(token),
 		action.WithGithubURL(github_url),
-	)
-	if err != nil {
-		fmt.Printf("Error creating action: %s\n", err)
-		os.Exit(exitClientInitError)
+		action.WithWorkspace(os.Getenv("GITHUB_WORKSPACE")),
+		action.WithCloneDepth(clone_depth),
+		action.WithCloneTimeout(clone_timeout),
+		action.WithOutputRepositoryURL(output_repository_url),
+		action.WithOutputRepositoryToken(output_repository_token),
+		action.WithWriteBackMode(write_back_mode),
+		action.WithPushRetries(push_retries),
+		action.WithGithubAPIURL(github_api_url),
+		action.WithWriteBackAllConfigurations(write_back_all_configurations),
+		action.WithCommitAuthorName(commit_author_name),
+		action.WithCommitAuthorEmail(commit_author_email),
+		action.WithCommitMessageTemplate(commit_message_template),
+		action.WithCommitSigningKey(commit_signing_key),
+		action.WithCommitSigningKeyPassphrase(commit_signing_key_passphrase),
+		action.WithSourceCommitSHA(os.Getenv("GITHUB_SHA")),
 	}
+}
 
-	logger.Info("Testing connection to BindPlane API")
-	version, err := action.TestConnection()
-	if err != nil {
-		fmt.Printf("Error testing connection: %s\n", err)
-		os.Exit(exitClientTestConnectionError)
+// runTargets runs fn with an action created from the options, or with an
+// action for each target when targets_file is set. When connect is true,
+// the connection to BindPlane is tested first and its version is set as
+// the bindplane_version output. The exit code is returned.
+func runTargets(logger *zap.Logger, opts []action.Option, connect bool, fn func(a *action.Action) error) int {
+	if len(targets) == 0 {
+		tag, code, err := runTarget(logger, opts, connect, fn)
+		if tag != "" {
+			if err := setOutput("bindplane_version", tag); err != nil {
+				logger.Warn("Failed to set bindplane_version output", zap.Error(err))
+			}
+		}
+		if err != nil {
+			logger.Error("error running action", zap.Error(err))
+		}
+		return code
 	}
-	logger.Info(
-		"Connection to BindPlane API successful",
-		zap.Any("bindplane_version", version.Tag),
-	)
 
-	if token != "" || github_url != "" {
-		// Retrieve the commit message from the head commit on the branch
-		message, err := commitMessage(github_url, currentBranch, token)
+	exitCode := 0
+	results := make([]targetResult, 0, len(targets))
+	versions := make(map[string]string, len(targets))
+	for _, t := range targets {
+		targetLogger := logger.With(zap.String("target", t.Name))
+		targetLogger.Info("Running action for target")
+
+		tag, code, err := runTarget(targetLogger, append(opts, t.Options()...), connect, fn)
 		if err != nil {
-			logger.Error("error getting commit message", zap.Error(err))
-			os.Exit(exitClientError)
+			targetLogger.Error("error running action", zap.Error(err))
+			if exitCode == 0 {
+				exitCode = code
+			}
+		}
+		if tag != "" {
+			versions[t.Name] = tag
+		}
+		results = append(results, targetResult{name: t.Name, version: tag, err: err})
+	}
+
+	// With multiple targets, the version output is a JSON
+	// object mapping each target name to its version.
+	if connect {
+		if b, err := json.Marshal(versions); err == nil {
+			if err := setOutput("bindplane_version", string(b)); err != nil {
+				logger.Warn("Failed to set bindplane_version output", zap.Error(err))
+			}
 		}
+	}
+
+	summarizeTargets(logger, results)
+	return exitCode
+}
+
+// runTarget creates an action with the given options and runs fn with
+// it, after testing the connection to BindPlane when connect is true.
+// The detected BindPlane version tag is returned along with an exit
+// code, which is zero when err is nil.
+func runTarget(logger *zap.Logger, opts []action.Option, connect bool, fn func(a *action.Action) error) (string, int, error) {
+	a, err := action.New(logger, opts...)
+	if err != nil {
+		return "", action.ExitCodeClientInit, fmt.Errorf("create action: %w", err)
+	}
 
-		// If the commit message contains `progress rollout <name>`, progress the rollout
-		// for the configuration instead of running the full workflow.
-		if name, ok := extractConfigName(message); ok {
-			err := action.RunRollout(name)
-			if err != nil {
-				logger.Error("error progressing rollout", zap.Error(err))
-				os.Exit(exitClientError)
+	tag := ""
+	if connect {
+		logger.Info("Testing connection to BindPlane API")
+		version, err := a.TestConnection()
+		if err != nil {
+			// Rejected credentials and timeouts have their own exit codes
+			code := action.ExitCode(err)
+			if code == action.ExitCodeError {
+				code = action.ExitCodeTestConnection
 			}
-			return
+			return "", code, fmt.Errorf("test connection: %w", err)
 		}
-	} else {
-		logger.Info("Skipping commit message check, Github token not provided")
+		logger.Info(
+			"Connection to BindPlane API successful",
+			zap.Any("bindplane_version", version.Tag),
+		)
+		tag = version.Tag
 	}
 
-	// Run the full workflow
-	if err := action.Run(); err != nil {
-		action.Logger.Error("error running action", zap.Error(err))
-		os.Exit(exitClientError)
+	if err := fn(a); err != nil {
+		return tag, action.ExitCode(err), err
 	}
 
-	os.Exit(0)
+	return tag, 0, nil
 }
 
-// commitMessage clones the repository and returns the commit message of the
-// head commit on the provided branch.
+// commitMessage returns the commit message of the head commit on the
+// provided branch. The workspace checkout is used when it has the branch
+// checked out, otherwise the repository is cloned.
 func commitMessage(cloneURL, branch, token string) (string, error) {
-	repo, err := repo.CloneRepo(cloneURL, branch, token)
+	proxy := config.Proxy{
+		URL:      proxy_url,
+		Username: proxy_username,
+		Password: proxy_password,
+		NoProxy:  no_proxy,
+	}
+
+	repo, err := repo.Open(repo.Options{
+		URL:       cloneURL,
+		Branch:    branch,
+		Token:     token,
+		Proxy:     proxy,
+		Workspace: os.Getenv("GITHUB_WORKSPACE"),
+		Depth:     clone_depth,
+		Timeout:   clone_timeout,
+	})
 	if err != nil {
 		return "", fmt.Errorf("clone repository branch %s: %w", branch, err)
 	}
+	defer func() { _ = repo.Close() }()
 
 	ref, err := repo.Head()
 	if err != nil {
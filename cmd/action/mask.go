@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/observiq/bindplane-op-action/internal/logging"
+)
+
+// masker masks secrets in logs. It is created by main before the logger.
+var masker *logging.Masker
+
+// newMasker returns a masker of the credential inputs. In GitHub Actions,
+// the secrets are registered with the runner using add-mask commands
+// written to stdout.
+func newMasker() *logging.Masker {
+	m := logging.NewMasker(nil)
+	if inActions() {
+		m = logging.NewMasker(os.Stdout)
+	}
+	m.Add(secretInputs()...)
+	return m
+}
+
+// secretInputs returns the values of inputs which hold credentials,
+// including passwords within URLs
+func secretInputs() []string {
+	secrets := []string{
+		bindplane_api_key,
+		bindplane_password,
+		token,
+		output_repository_token,
+		proxy_password,
+		commit_signing_key,
+		commit_signing_key_passphrase,
+	}
+
+	for _, u := range []string{bindplane_remote_url, github_url, output_repository_url, proxy_url} {
+		secrets = append(secrets, urlPassword(u))
+	}
+	return secrets
+}
+
+// targetSecrets returns the credentials of targets
+func targetSecrets(t []action.Target) []string {
+	secrets := make([]string, 0, len(t)*2)
+	for _, target := range t {
+		secrets = append(secrets, target.APIKey, target.Password, urlPassword(target.RemoteURL))
+	}
+	return secrets
+}
+
+// urlPassword returns the password of a URL, such as the token of
+// https://x-access-token:<token>@github.com/org/repo.git
+func urlPassword(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return ""
+	}
+	password, _ := u.User.Password()
+	return password
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// setOutput sets a step output by appending it to the file referenced
+// by GITHUB_OUTPUT. It is a no-op outside of a GitHub runner.
+func setOutput(name, value string) error {
+	return appendRunnerFile("GITHUB_OUTPUT", fmt.Sprintf("%s=%s\n", name, value))
+}
+
+// appendRunnerFile appends contents to the file referenced by the
+// environment variable env, such as GITHUB_OUTPUT or GITHUB_STEP_SUMMARY.
+// It is a no-op when the environment variable is not set.
+func appendRunnerFile(env, contents string) error {
+	path := os.Getenv(env)
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 path is set by the runner
+	if err != nil {
+		return fmt.Errorf("open %s: %w", env, err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(contents); err != nil {
+		return fmt.Errorf("write %s: %w", env, err)
+	}
+
+	return nil
+}
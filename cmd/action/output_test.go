@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOutput(t *testing.T) {
+	t.Setenv("GITHUB_OUTPUT", "")
+	require.NoError(t, setOutput("bindplane_version", "v1.91.0"))
+
+	path := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", path)
+
+	require.NoError(t, setOutput("bindplane_version", "v1.91.0"))
+	require.NoError(t, setOutput("other", "value"))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "bindplane_version=v1.91.0\nother=value\n", string(b))
+}
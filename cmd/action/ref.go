@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/internal/repo"
+)
+
+const (
+	branchRefPrefix = "refs/heads/"
+	tagRefPrefix    = "refs/tags/"
+)
+
+// ref is the git reference the action is running for
+type ref struct {
+	// full is the full reference name, such as refs/heads/release/1.0
+	full string
+
+	// name is the short name of a branch or tag, such as release/1.0
+	// or v1.2.3. It is empty for other references, such as pull
+	// request merge refs.
+	name string
+
+	tag bool
+}
+
+// branch returns the branch name, or an empty string for tags
+func (r ref) branch() string {
+	if r.tag {
+		return ""
+	}
+	return r.name
+}
+
+// parseRef parses a full reference name. Branch names may contain slashes.
+func parseRef(full string) ref {
+	switch {
+	case strings.HasPrefix(full, branchRefPrefix):
+		return ref{full: full, name: strings.TrimPrefix(full, branchRefPrefix)}
+	case strings.HasPrefix(full, tagRefPrefix):
+		return ref{full: full, name: strings.TrimPrefix(full, tagRefPrefix), tag: true}
+	default:
+		return ref{full: full}
+	}
+}
+
+// branchRef returns the ref of a branch
+func branchRef(name string) ref {
+	return ref{full: branchRefPrefix + name, name: name}
+}
+
+// githubRef returns the ref of the workflow run. Pull request runs use the
+// head branch from GITHUB_HEAD_REF. Release and tag push runs use the tag,
+// which GitHub sets as GITHUB_REF.
+func githubRef() ref {
+	if head := os.Getenv("GITHUB_HEAD_REF"); head != "" {
+		return branchRef(head)
+	}
+	return parseRef(os.Getenv("GITHUB_REF"))
+}
+
+// currentRef returns the ref the action is running for. In GitHub Actions
+// it is read from the runner environment, otherwise it is the branch
+// checked out in the working directory, if any.
+func currentRef() ref {
+	if inActions() {
+		return githubRef()
+	}
+
+	branch, err := repo.CurrentBranch(".")
+	if err != nil || branch == "" {
+		return ref{}
+	}
+	return branchRef(branch)
+}
+
+// isTargetRef returns true if the action should run for the ref, because
+// it is target_branch, matches an environment or matches target_ref
+func isTargetRef(r ref) bool {
+	if r.name == "" {
+		return false
+	}
+
+	if (!r.tag && r.name == target_branch) || environment != "" {
+		return true
+	}
+
+	return matchesTargetRef(r)
+}
+
+// matchesTargetRef returns true if the ref matches a target_ref pattern.
+// Patterns starting with refs/ are matched against the full reference
+// name, and others against the short branch or tag name.
+func matchesTargetRef(r ref) bool {
+	for _, p := range target_ref {
+		name := r.name
+		if strings.HasPrefix(p, "refs/") {
+			name = r.full
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRef(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		expect ref
+	}{
+		{"Branch", "refs/heads/main", ref{full: "refs/heads/main", name: "main"}},
+		{"Branch with slashes", "refs/heads/release/1.0", ref{full: "refs/heads/release/1.0", name: "release/1.0"}},
+		{"Tag", "refs/tags/v1.2.3", ref{full: "refs/tags/v1.2.3", name: "v1.2.3", tag: true}},
+		{"Pull request", "refs/pull/12/merge", ref{full: "refs/pull/12/merge"}},
+		{"Empty", "", ref{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expect, parseRef(tc.input))
+		})
+	}
+}
+
+func TestGithubRef(t *testing.T) {
+	cases := []struct {
+		name   string
+		env    map[string]string
+		expect ref
+	}{
+		{
+			"Push",
+			map[string]string{"GITHUB_REF": "refs/heads/feature/login"},
+			ref{full: "refs/heads/feature/login", name: "feature/login"},
+		},
+		{
+			"Pull request",
+			map[string]string{"GITHUB_REF": "refs/pull/12/merge", "GITHUB_HEAD_REF": "feature/login"},
+			ref{full: "refs/heads/feature/login", name: "feature/login"},
+		},
+		{
+			"Release",
+			map[string]string{"GITHUB_REF": "refs/tags/v2.0.0", "GITHUB_EVENT_NAME": "release"},
+			ref{full: "refs/tags/v2.0.0", name: "v2.0.0", tag: true},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GITHUB_HEAD_REF", "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			require.Equal(t, tc.expect, githubRef())
+		})
+	}
+}
+
+func TestIsTargetRef(t *testing.T) {
+	cases := []struct {
+		name         string
+		targetBranch string
+		targetRef    []string
+		environment  string
+		ref          string
+		expect       bool
+	}{
+		{"Target branch", "main", nil, "", "refs/heads/main", true},
+		{"Target branch with slashes", "release/1.0", nil, "", "refs/heads/release/1.0", true},
+		{"Other branch", "main", nil, "", "refs/heads/develop", false},
+		{"Tag named like target branch", "main", nil, "", "refs/tags/main", false},
+		{"Tag pattern", "main", []string{"v*"}, "", "refs/tags/v1.2.3", true},
+		{"Tag pattern mismatch", "main", []string{"v*"}, "", "refs/tags/1.2.3", false},
+		{"Full ref pattern", "", []string{"refs/tags/v*"}, "", "refs/tags/v1.2.3", true},
+		{"Full ref pattern excludes branches", "", []string{"refs/tags/v*"}, "", "refs/heads/v1", false},
+		{"Branch pattern", "", []string{"release/*"}, "", "refs/heads/release/1.0", true},
+		{"Environment", "main", nil, "develop", "refs/heads/develop", true},
+		{"Pull request merge ref", "main", []string{"*"}, "", "refs/pull/1/merge", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			target_branch = tc.targetBranch
+			target_ref = tc.targetRef
+			environment = tc.environment
+			defer resetInputs()
+
+			require.Equal(t, tc.expect, isTargetRef(parseRef(tc.ref)))
+		})
+	}
+}
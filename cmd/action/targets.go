@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// targetResult is the outcome of running the action against a target
+type targetResult struct {
+	name    string
+	version string
+	err     error
+}
+
+// summarizeTargets logs the result of each target. When running in a
+// GitHub runner, a markdown table is also appended to the job summary.
+func summarizeTargets(logger *zap.Logger, results []targetResult) {
+	b := strings.Builder{}
+	b.WriteString("### Bindplane targets\n\n")
+	b.WriteString("| Target | Bindplane Version | Status | Error |\n")
+	b.WriteString("| :----- | :---------------- | :----- | :---- |\n")
+
+	for _, r := range results {
+		if r.err != nil {
+			logger.Error("Target failed", zap.String("target", r.name), zap.Error(r.err))
+			fmt.Fprintf(&b, "| %s | %s | failed | %s |\n", r.name, r.version, markdownCell(masker.Scrub(r.err.Error())))
+			continue
+		}
+		logger.Info("Target succeeded", zap.String("target", r.name))
+		fmt.Fprintf(&b, "| %s | %s | succeeded | |\n", r.name, r.version)
+	}
+
+	if err := appendRunnerFile("GITHUB_STEP_SUMMARY", b.String()); err != nil {
+		logger.Warn("Failed to write job summary", zap.Error(err))
+	}
+}
+
+// markdownCell escapes a string so it can be placed in a markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -4,31 +4,58 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/observiq/bindplane-op-action/action"
+	"github.com/observiq/bindplane-op-action/internal/client"
 	"github.com/observiq/bindplane-op-action/internal/client/model"
 	"github.com/observiq/bindplane-op-action/internal/glob"
+	"github.com/observiq/bindplane-op-action/internal/signing"
 )
 
 func validate() error {
-	if err := validateRemoteURL(); err != nil {
-		return err
+	if targets_file != "" {
+		if err := validateTargets(); err != nil {
+			return err
+		}
+	} else {
+		if err := validateRemoteURL(); err != nil {
+			return err
+		}
+
+		if err := validateAuth(); err != nil {
+			return err
+		}
+	}
+
+	// Outside of GitHub Actions, the current branch is not
+	// known and the action runs regardless of target_branch
+	if inActions() {
+		if err := validateTargetBranch(); err != nil {
+			return err
+		}
+
+		if err := validateActionsEnvironment(); err != nil {
+			return err
+		}
 	}
 
-	if err := validateTargetBranch(); err != nil {
+	if err := validateWriteBack(); err != nil {
 		return err
 	}
 
-	if err := validateAuth(); err != nil {
+	if err := validateProxy(); err != nil {
 		return err
 	}
 
-	if err := validateWriteBack(); err != nil {
+	if err := validateTLS(); err != nil {
 		return err
 	}
 
-	if err := validateActionsEnvironment(); err != nil {
+	if err := validateClone(); err != nil {
 		return err
 	}
 
@@ -40,38 +67,137 @@ func validate() error {
 }
 
 func validateRemoteURL() error {
-	if bindplane_remote_url == "" {
-		return fmt.Errorf("bindplane_remote_url is required")
+	return checkRemoteURL("bindplane_remote_url", bindplane_remote_url)
+}
+
+func checkRemoteURL(name, remoteURL string) error {
+	if remoteURL == "" {
+		return fmt.Errorf("%s is required", name)
 	}
 
-	u, err := url.Parse(bindplane_remote_url)
+	u, err := url.Parse(remoteURL)
 	if err != nil {
-		return fmt.Errorf("bindplane_remote_url is not a valid URL: %s", err)
+		return fmt.Errorf("%s is not a valid URL: %s", name, err)
 	}
 
 	if u.Scheme != "http" && u.Scheme != "https" {
-		return fmt.Errorf("bindplane_remote_url must be an http or https URL")
+		return fmt.Errorf("%s must be an http or https URL", name)
+	}
+
+	return nil
+}
+
+func validateClone() error {
+	if clone_depth < 0 {
+		return fmt.Errorf("clone_depth must not be negative")
+	}
+
+	if clone_timeout <= 0 {
+		return fmt.Errorf("clone_timeout must be greater than zero")
 	}
 
 	return nil
 }
 
 func validateTargetBranch() error {
-	if target_branch == "" {
-		return fmt.Errorf("target_branch is required")
+	if target_branch == "" && len(environment_patterns) == 0 && len(target_ref) == 0 {
+		return fmt.Errorf("target_branch is required when target_ref and branch environments are not set")
+	}
+
+	for _, p := range target_ref {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("target_ref pattern %q is invalid: %s", p, err)
+		}
 	}
 	return nil
 }
 
 func validateAuth() error {
-	if bindplane_api_key == "" && bindplane_username == "" {
-		return fmt.Errorf("either bindplane_api_key or bindplane_username is required")
+	return checkAuth("", bindplane_api_key, bindplane_username, bindplane_password, bindplane_oidc_token_url)
+}
+
+// checkAuth validates a set of credentials. The prefix is prepended to
+// error messages to identify the target being validated.
+func checkAuth(prefix, apiKey, username, password, oidcTokenURL string) error {
+	if apiKey == "" && username == "" && oidcTokenURL == "" {
+		return fmt.Errorf("%seither bindplane_api_key, bindplane_username or bindplane_oidc_token_url is required", prefix)
 	}
 
-	if bindplane_username != "" && bindplane_password == "" {
-		return fmt.Errorf("bindplane_password is required when using bindplane_username")
+	if username != "" && password == "" {
+		return fmt.Errorf("%sbindplane_password is required when using bindplane_username", prefix)
+	}
+
+	if oidcTokenURL != "" {
+		u, err := url.Parse(oidcTokenURL)
+		if err != nil {
+			return fmt.Errorf("%sbindplane_oidc_token_url is not a valid URL: %s", prefix, err)
+		}
+
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("%sbindplane_oidc_token_url must be an http or https URL", prefix)
+		}
+	}
+
+	return nil
+}
+
+// validateTargets loads the targets file and validates each target. Targets
+// inherit the remote URL and credentials from the top level options when
+// they do not set their own.
+func validateTargets() error {
+	t, err := action.LoadTargets(targets_file)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range t {
+		remoteURL := target.RemoteURL
+		if remoteURL == "" {
+			remoteURL = bindplane_remote_url
+		}
+		if err := checkRemoteURL(fmt.Sprintf("target %s: remote_url", target.Name), remoteURL); err != nil {
+			return err
+		}
+
+		apiKey, username, password, oidcTokenURL := bindplane_api_key, bindplane_username, bindplane_password, bindplane_oidc_token_url
+		if target.APIKey != "" || target.Username != "" || target.OIDCTokenURL != "" {
+			apiKey, username, password, oidcTokenURL = target.APIKey, target.Username, target.Password, target.OIDCTokenURL
+		}
+		if err := checkAuth(fmt.Sprintf("target %s: ", target.Name), apiKey, username, password, oidcTokenURL); err != nil {
+			return err
+		}
+	}
+
+	if err := checkTargetOutputDirs(t); err != nil {
+		return err
+	}
+
+	targets = t
+	return nil
+}
+
+// checkTargetOutputDirs returns an error if targets write back to the same
+// configuration output directory. The manifest of a directory records the
+// outputs of a single BindPlane server, so each target would remove the
+// outputs of the others.
+func checkTargetOutputDirs(t []action.Target) error {
+	if !enable_otel_config_write_back {
+		return nil
 	}
 
+	dirs := make(map[string]string, len(t))
+	for _, target := range t {
+		dir := target.ConfigurationOutputDir
+		if dir == "" {
+			dir = configuration_output_dir
+		}
+		dir = path.Clean(filepath.ToSlash(dir))
+
+		if other, ok := dirs[dir]; ok {
+			return fmt.Errorf("targets %s and %s: configuration_output_dir %s is shared, each target must write back to its own directory", other, target.Name, dir)
+		}
+		dirs[dir] = target.Name
+	}
 	return nil
 }
 
@@ -84,20 +210,30 @@ func validateWriteBack() error {
 		return fmt.Errorf("configuration_output_dir is required when enable_otel_config_write_back is true")
 	}
 
-	// configuration_output_branch is optional and should be set to
-	// target_branch if not provided by the user. If it is empty here,
-	// it means we failed to set it in parseArgs or failed to validate
-	// that target_branch was set.
+	// configuration_output_branch is optional and is set to target_branch
+	// if not provided by the user. Outside of GitHub Actions, target_branch
+	// is not required, so neither may be set.
 	if configuration_output_branch == "" {
-		return fmt.Errorf("configuration_output_branch is not set. %s", action.BugError)
+		return fmt.Errorf("configuration_output_branch or target_branch is required when enable_otel_config_write_back is true")
 	}
 
 	// If a token is not set, github_url is required because it can contain
-	// the token.
-	if token == "" && github_url == "" {
+	// the token. Neither is used when writing to a separate output repository.
+	if output_repository_url == "" && token == "" && github_url == "" {
 		return fmt.Errorf("either token or github_url is required when enable_otel_config_write_back is true")
 	}
 
+	if output_repository_url != "" {
+		p, err := url.Parse(output_repository_url)
+		if err != nil {
+			return fmt.Errorf("output_repository_url is not a valid URL: %s", err)
+		}
+
+		if p.Scheme != "http" && p.Scheme != "https" {
+			return fmt.Errorf("output_repository_url must be an http or https URL")
+		}
+	}
+
 	if github_url != "" {
 		p, err := url.Parse(github_url)
 		if err != nil {
@@ -110,6 +246,84 @@ func validateWriteBack() error {
 		}
 	}
 
+	switch write_back_mode {
+	case action.WriteBackModePush:
+	case action.WriteBackModePullRequest:
+		// The token is required to authenticate to the GitHub API
+		if output_repository_url != "" && output_repository_token == "" {
+			return fmt.Errorf("output_repository_token is required when write_back_mode is %s", action.WriteBackModePullRequest)
+		}
+		if output_repository_url == "" && token == "" {
+			return fmt.Errorf("token is required when write_back_mode is %s", action.WriteBackModePullRequest)
+		}
+	default:
+		return fmt.Errorf("write_back_mode must be one of %s or %s", action.WriteBackModePush, action.WriteBackModePullRequest)
+	}
+
+	if err := action.ValidateRedactKeyPatterns(redact_key_patterns); err != nil {
+		return fmt.Errorf("redact_key_patterns: %s", err)
+	}
+
+	if push_retries < 0 {
+		return fmt.Errorf("push_retries must not be negative")
+	}
+
+	if !slices.Contains(action.OutputFormats, configuration_output_format) {
+		return fmt.Errorf("configuration_output_format must be one of %s", strings.Join(action.OutputFormats, ", "))
+	}
+
+	if _, err := action.ParseCommitMessageTemplate(commit_message_template); err != nil {
+		return fmt.Errorf("commit_message_template is not a valid template: %s", err)
+	}
+
+	if commit_signing_key != "" {
+		if _, err := signing.New(commit_signing_key, commit_signing_key_passphrase); err != nil {
+			return fmt.Errorf("commit_signing_key is not valid: %s", err)
+		}
+	}
+
+	if github_api_url != "" {
+		p, err := url.Parse(github_api_url)
+		if err != nil {
+			return fmt.Errorf("github_api_url is not a valid URL: %s", err)
+		}
+
+		if p.Scheme != "http" && p.Scheme != "https" {
+			return fmt.Errorf("github_api_url must be an http or https URL")
+		}
+	}
+
+	return nil
+}
+
+func validateProxy() error {
+	if proxy_url != "" {
+		u, err := url.Parse(proxy_url)
+		if err != nil {
+			return fmt.Errorf("proxy_url is not a valid URL: %s", err)
+		}
+
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("proxy_url must be an http or https URL")
+		}
+	}
+
+	if proxy_password != "" && proxy_username == "" {
+		return fmt.Errorf("proxy_username is required when using proxy_password")
+	}
+
+	return nil
+}
+
+// validateTLS loads the certificate authorities of tls_ca_cert, which
+// may be inline PEM or paths to files and directories
+func validateTLS() error {
+	cas, err := client.LoadCertificateAuthorities(tls_ca_cert)
+	if err != nil {
+		return fmt.Errorf("tls_ca_cert: %s", err)
+	}
+
+	tls_ca_certs = cas
 	return nil
 }
 
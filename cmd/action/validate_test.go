@@ -3,8 +3,11 @@ package main
 import (
 	"errors"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/observiq/bindplane-op-action/action"
 	"github.com/stretchr/testify/require"
 )
 
@@ -52,6 +55,16 @@ func TestValidateTargetBranch(t *testing.T) {
 	require.NoError(t, validateTargetBranch())
 }
 
+func TestValidateTargetRef(t *testing.T) {
+	defer resetInputs()
+
+	target_ref = []string{"v*"}
+	require.NoError(t, validateTargetBranch(), "target_branch is not required with target_ref")
+
+	target_ref = []string{"v[0-9"}
+	require.ErrorContains(t, validateTargetBranch(), `target_ref pattern "v[0-9" is invalid`)
+}
+
 func TestValidateAuth(t *testing.T) {
 	cases := []struct {
 		name string
@@ -65,7 +78,7 @@ func TestValidateAuth(t *testing.T) {
 			"",
 			"",
 			"",
-			errors.New("either bindplane_api_key or bindplane_username is required"),
+			errors.New("either bindplane_api_key, bindplane_username or bindplane_oidc_token_url is required"),
 		},
 		{
 			"Valid key",
@@ -107,6 +120,17 @@ func TestValidateAuth(t *testing.T) {
 	}
 }
 
+func TestValidateAuthOIDC(t *testing.T) {
+	bindplane_oidc_token_url = "https://auth.corp.net/token"
+	defer func() {
+		bindplane_oidc_token_url = ""
+	}()
+	require.NoError(t, validateAuth())
+
+	bindplane_oidc_token_url = "auth.corp.net/token"
+	require.Equal(t, errors.New("bindplane_oidc_token_url must be an http or https URL"), validateAuth())
+}
+
 func ValidateWriteBack(t *testing.T) {
 	cases := []struct {
 		name            string
@@ -194,6 +218,236 @@ func ValidateWriteBack(t *testing.T) {
 	}
 }
 
+func TestValidateWriteBackMode(t *testing.T) {
+	cases := []struct {
+		name   string
+		mode   string
+		token  string
+		apiURL string
+		err    error
+	}{
+		{
+			"Push",
+			action.WriteBackModePush,
+			"",
+			"",
+			nil,
+		},
+		{
+			"Pull request",
+			action.WriteBackModePullRequest,
+			"token",
+			"https://ghe.corp.net/api/v3",
+			nil,
+		},
+		{
+			"Pull request without token",
+			action.WriteBackModePullRequest,
+			"",
+			"",
+			errors.New("token is required when write_back_mode is pull_request"),
+		},
+		{
+			"Invalid mode",
+			"merge",
+			"token",
+			"",
+			errors.New("write_back_mode must be one of push or pull_request"),
+		},
+		{
+			"Invalid API URL",
+			action.WriteBackModePush,
+			"token",
+			"ghe.corp.net/api/v3",
+			errors.New("github_api_url must be an http or https URL"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			enable_otel_config_write_back = true
+			configuration_output_dir = "otel"
+			configuration_output_branch = "main"
+			github_url = "https://github.com/observIQ/configs.git"
+			write_back_mode = tc.mode
+			configuration_output_format = action.OutputFormatRaw
+			token = tc.token
+			github_api_url = tc.apiURL
+			defer func() {
+				enable_otel_config_write_back = false
+				configuration_output_dir = ""
+				configuration_output_branch = ""
+				github_url = ""
+				write_back_mode = ""
+				configuration_output_format = ""
+				token = ""
+				github_api_url = ""
+			}()
+
+			require.Equal(t, tc.err, validateWriteBack())
+		})
+	}
+}
+
+func TestValidateProxy(t *testing.T) {
+	cases := []struct {
+		name     string
+		proxyURL string
+		username string
+		password string
+		err      error
+	}{
+		{
+			"No proxy",
+			"",
+			"",
+			"",
+			nil,
+		},
+		{
+			"Valid proxy",
+			"http://proxy.corp.net:3128",
+			"user",
+			"pass",
+			nil,
+		},
+		{
+			"Invalid proxy scheme",
+			"socks5://proxy.corp.net:1080",
+			"",
+			"",
+			errors.New("proxy_url must be an http or https URL"),
+		},
+		{
+			"Password without username",
+			"http://proxy.corp.net:3128",
+			"",
+			"pass",
+			errors.New("proxy_username is required when using proxy_password"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			proxy_url = tc.proxyURL
+			proxy_username = tc.username
+			proxy_password = tc.password
+			defer func() {
+				proxy_url = ""
+				proxy_username = ""
+				proxy_password = ""
+			}()
+
+			require.Equal(t, tc.err, validateProxy())
+		})
+	}
+}
+
+func TestValidateTargets(t *testing.T) {
+	cases := []struct {
+		name      string
+		remoteURL string
+		apiKey    string
+		contents  string
+		err       error
+	}{
+		{
+			"Targets with own remote URL and credentials",
+			"",
+			"",
+			"targets:\n  - name: prod\n    remote_url: https://prod.corp.net\n    api_key: key",
+			nil,
+		},
+		{
+			"Targets inherit remote URL and credentials",
+			"https://bindplane.corp.net",
+			"key",
+			"targets:\n  - name: prod\n  - name: staging",
+			nil,
+		},
+		{
+			"Target missing remote URL",
+			"",
+			"key",
+			"targets:\n  - name: prod",
+			errors.New("target prod: remote_url is required"),
+		},
+		{
+			"Target missing credentials",
+			"https://bindplane.corp.net",
+			"",
+			"targets:\n  - name: prod",
+			errors.New("target prod: either bindplane_api_key, bindplane_username or bindplane_oidc_token_url is required"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "targets.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.contents), 0600))
+
+			targets_file = path
+			bindplane_remote_url = tc.remoteURL
+			bindplane_api_key = tc.apiKey
+			defer func() {
+				targets_file = ""
+				bindplane_remote_url = ""
+				bindplane_api_key = ""
+				targets = nil
+			}()
+
+			require.Equal(t, tc.err, validateTargets())
+		})
+	}
+}
+
+func TestValidateTargetsOutputDir(t *testing.T) {
+	cases := []struct {
+		name      string
+		writeBack bool
+		contents  string
+		expectErr string
+	}{
+		{
+			name:      "Separate directories",
+			writeBack: true,
+			contents:  "targets:\n  - name: prod\n    configuration_output_dir: otel/prod\n  - name: staging\n    configuration_output_dir: otel/staging",
+		},
+		{
+			name:      "Shared directory",
+			writeBack: true,
+			contents:  "targets:\n  - name: prod\n    configuration_output_dir: otel/\n  - name: staging",
+			expectErr: "targets prod and staging: configuration_output_dir otel is shared",
+		},
+		{
+			name:     "Shared directory without write back",
+			contents: "targets:\n  - name: prod\n  - name: staging",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "targets.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.contents), 0600))
+
+			targets_file = path
+			bindplane_remote_url = "https://bindplane.corp.net"
+			bindplane_api_key = "key"
+			enable_otel_config_write_back = tc.writeBack
+			configuration_output_dir = "otel"
+			defer resetInputs()
+			defer func() { targets = nil }()
+
+			err := validateTargets()
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.expectErr)
+		})
+	}
+}
+
 func TestValidateActionsEnvironment(t *testing.T) {
 	// Skip if running in github actions
 	if os.Getenv("GITHUB_ACTOR") == "" {
@@ -210,3 +464,284 @@ func TestValidateActionsEnvironment(t *testing.T) {
 
 	require.NoError(t, validateActionsEnvironment())
 }
+
+func TestValidateWriteBackCommit(t *testing.T) {
+	cases := []struct {
+		name       string
+		template   string
+		signingKey string
+		err        string
+	}{
+		{
+			name:     "Default template",
+			template: action.DefaultCommitMessageTemplate,
+		},
+		{
+			name:     "Template",
+			template: "Render {{ join .Configurations \", \" }} from {{ .SHA }}",
+		},
+		{
+			name:     "Invalid template",
+			template: "{{ .SHA",
+			err:      "commit_message_template is not a valid template",
+		},
+		{
+			name:       "Invalid signing key",
+			template:   action.DefaultCommitMessageTemplate,
+			signingKey: "not a key",
+			err:        "commit_signing_key is not valid",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			enable_otel_config_write_back = true
+			configuration_output_dir = "otel"
+			configuration_output_branch = "main"
+			github_url = "https://github.com/observIQ/configs.git"
+			write_back_mode = action.WriteBackModePush
+			configuration_output_format = action.OutputFormatRaw
+			commit_message_template = tc.template
+			commit_signing_key = tc.signingKey
+			defer func() {
+				enable_otel_config_write_back = false
+				configuration_output_dir = ""
+				configuration_output_branch = ""
+				github_url = ""
+				write_back_mode = ""
+				configuration_output_format = ""
+				commit_message_template = ""
+				commit_signing_key = ""
+			}()
+
+			err := validateWriteBack()
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		err    error
+	}{
+		{"Raw", action.OutputFormatRaw, nil},
+		{"ConfigMap", action.OutputFormatConfigMap, nil},
+		{"Helm", action.OutputFormatHelm, nil},
+		{"Split", action.OutputFormatSplit, nil},
+		{"Invalid", "json", errors.New("configuration_output_format must be one of raw, configmap, helm, split")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			enable_otel_config_write_back = true
+			configuration_output_dir = "otel"
+			configuration_output_branch = "main"
+			github_url = "https://github.com/observIQ/configs.git"
+			write_back_mode = action.WriteBackModePush
+			configuration_output_format = tc.format
+			defer func() {
+				enable_otel_config_write_back = false
+				configuration_output_dir = ""
+				configuration_output_branch = ""
+				github_url = ""
+				write_back_mode = ""
+				configuration_output_format = ""
+			}()
+
+			require.Equal(t, tc.err, validateWriteBack())
+		})
+	}
+}
+
+func TestValidateOutputRepository(t *testing.T) {
+	cases := []struct {
+		name      string
+		url       string
+		repoToken string
+		mode      string
+		err       error
+	}{
+		{
+			"Push",
+			"https://github.com/observIQ/deployments.git",
+			"",
+			action.WriteBackModePush,
+			nil,
+		},
+		{
+			"Pull request",
+			"https://github.com/observIQ/deployments.git",
+			"token",
+			action.WriteBackModePullRequest,
+			nil,
+		},
+		{
+			"Pull request without token",
+			"https://github.com/observIQ/deployments.git",
+			"",
+			action.WriteBackModePullRequest,
+			errors.New("output_repository_token is required when write_back_mode is pull_request"),
+		},
+		{
+			"SSH URL",
+			"ssh://git@github.com/observIQ/deployments.git",
+			"token",
+			action.WriteBackModePush,
+			errors.New("output_repository_url must be an http or https URL"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			enable_otel_config_write_back = true
+			configuration_output_dir = "otel"
+			configuration_output_branch = "main"
+			write_back_mode = tc.mode
+			configuration_output_format = action.OutputFormatRaw
+			output_repository_url = tc.url
+			output_repository_token = tc.repoToken
+			defer func() {
+				enable_otel_config_write_back = false
+				configuration_output_dir = ""
+				configuration_output_branch = ""
+				write_back_mode = ""
+				configuration_output_format = ""
+				output_repository_url = ""
+				output_repository_token = ""
+			}()
+
+			err := validateWriteBack()
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err.Error())
+		})
+	}
+}
+
+func TestValidatePushRetries(t *testing.T) {
+	cases := []struct {
+		name    string
+		retries int
+		err     error
+	}{
+		{"Default", action.DefaultPushRetries, nil},
+		{"Disabled", 0, nil},
+		{"Negative", -1, errors.New("push_retries must not be negative")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			enable_otel_config_write_back = true
+			configuration_output_dir = "otel"
+			configuration_output_branch = "main"
+			github_url = "https://github.com/observIQ/configs.git"
+			write_back_mode = action.WriteBackModePush
+			configuration_output_format = action.OutputFormatRaw
+			push_retries = tc.retries
+			defer func() {
+				enable_otel_config_write_back = false
+				configuration_output_dir = ""
+				configuration_output_branch = ""
+				github_url = ""
+				write_back_mode = ""
+				configuration_output_format = ""
+				push_retries = 0
+			}()
+
+			require.Equal(t, tc.err, validateWriteBack())
+		})
+	}
+}
+
+func TestValidateClone(t *testing.T) {
+	cases := []struct {
+		name    string
+		depth   int
+		timeout time.Duration
+		err     error
+	}{
+		{"Shallow", 1, time.Minute, nil},
+		{"Full history", 0, time.Minute, nil},
+		{"Negative depth", -1, time.Minute, errors.New("clone_depth must not be negative")},
+		{"Zero timeout", 1, 0, errors.New("clone_timeout must be greater than zero")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clone_depth = tc.depth
+			clone_timeout = tc.timeout
+			defer func() {
+				clone_depth = 0
+				clone_timeout = 0
+			}()
+
+			require.Equal(t, tc.err, validateClone())
+		})
+	}
+}
+
+func TestValidateRedactKeyPatterns(t *testing.T) {
+	cases := []struct {
+		name     string
+		patterns []string
+		err      string
+	}{
+		{"Default", action.DefaultRedactKeyPatterns, ""},
+		{"Malformed", []string{"*api_key*", "[token"}, `redact_key_patterns: invalid pattern "[token"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			enable_otel_config_write_back = true
+			configuration_output_dir = "otel"
+			configuration_output_branch = "main"
+			github_url = "https://github.com/observIQ/configs.git"
+			write_back_mode = action.WriteBackModePush
+			configuration_output_format = action.OutputFormatRaw
+			redact_key_patterns = tc.patterns
+			defer func() {
+				enable_otel_config_write_back = false
+				configuration_output_dir = ""
+				configuration_output_branch = ""
+				github_url = ""
+				write_back_mode = ""
+				configuration_output_format = ""
+				redact_key_patterns = nil
+			}()
+
+			err := validateWriteBack()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	defer func() {
+		tls_ca_cert = ""
+		tls_ca_certs = nil
+	}()
+
+	tls_ca_cert = ""
+	require.NoError(t, validateTLS())
+	require.Empty(t, tls_ca_certs)
+
+	tls_ca_cert = filepath.Join(t.TempDir(), "missing.pem")
+	require.ErrorContains(t, validateTLS(), "tls_ca_cert: stat "+tls_ca_cert)
+
+	dir := t.TempDir()
+	tls_ca_cert = filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(tls_ca_cert, []byte("not a certificate"), 0600))
+	require.EqualError(t, validateTLS(), "tls_ca_cert: "+tls_ca_cert+": no PEM encoded certificates found")
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// pemPrefix starts every PEM block. Values containing it are inline
+// certificates rather than paths.
+const pemPrefix = "-----BEGIN"
+
+// certificateExtensions are the extensions of the files read from
+// certificate authority directories
+var certificateExtensions = []string{".pem", ".crt", ".cer"}
+
+// LoadCertificateAuthorities returns the PEM encoded certificate
+// authorities of value. The value is either inline PEM, which may contain
+// several certificates, or a comma separated list of paths to PEM files
+// and directories. Directories are not walked recursively, and only files
+// with a .pem, .crt or .cer extension are read from them. Each certificate
+// authority is checked to contain at least one certificate.
+func LoadCertificateAuthorities(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	if strings.Contains(value, pemPrefix) {
+		if err := checkCertificateAuthority(value); err != nil {
+			return nil, fmt.Errorf("inline certificate: %w", err)
+		}
+		return []string{value}, nil
+	}
+
+	var cas []string
+	for _, p := range strings.Split(value, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		files, err := certificateFiles(p)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			b, err := os.ReadFile(file) // #nosec G304 user defined filepath
+			if err != nil {
+				return nil, fmt.Errorf("read %s: %w", file, err)
+			}
+			if err := checkCertificateAuthority(string(b)); err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			cas = append(cas, string(b))
+		}
+	}
+	return cas, nil
+}
+
+// certificateFiles returns the path if it is a file, or the certificate
+// files within it if it is a directory
+func certificateFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: %w", path, err)
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("read directory %s: %w", path, err)
+	}
+
+	var files []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || !slices.Contains(certificateExtensions, ext) {
+			continue
+		}
+		files = append(files, filepath.Join(path, e.Name()))
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("directory %s does not contain any %s files", path, strings.Join(certificateExtensions, ", "))
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// checkCertificateAuthority returns an error if the PEM does not
+// contain a certificate
+func checkCertificateAuthority(pem string) error {
+	if !x509.NewCertPool().AppendCertsFromPEM([]byte(pem)) {
+		return fmt.Errorf("no PEM encoded certificates found")
+	}
+	return nil
+}
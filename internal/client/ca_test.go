@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testCertificate returns a PEM encoded self signed certificate
+func testCertificate(t *testing.T, name string) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestLoadCertificateAuthorities(t *testing.T) {
+	ca1 := testCertificate(t, "ca1")
+	ca2 := testCertificate(t, "ca2")
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "ca1.pem")
+	require.NoError(t, os.WriteFile(file, []byte(ca1), 0600))
+
+	bundleDir := filepath.Join(dir, "bundle")
+	require.NoError(t, os.MkdirAll(bundleDir, 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "b.crt"), []byte(ca2), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "a.pem"), []byte(ca1), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "README.txt"), []byte("not a certificate"), 0600))
+
+	emptyDir := filepath.Join(dir, "empty")
+	require.NoError(t, os.MkdirAll(emptyDir, 0750))
+
+	invalid := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, os.WriteFile(invalid, []byte("not a certificate"), 0600))
+
+	cases := []struct {
+		name      string
+		value     string
+		expect    []string
+		expectErr string
+	}{
+		{name: "empty", value: "", expect: nil},
+		{name: "inline", value: ca1, expect: []string{strings.TrimSpace(ca1)}},
+		{name: "inline bundle", value: ca1 + ca2, expect: []string{strings.TrimSpace(ca1 + ca2)}},
+		{name: "file", value: file, expect: []string{ca1}},
+		{name: "directory", value: bundleDir, expect: []string{ca1, ca2}},
+		{name: "list", value: file + ", " + bundleDir, expect: []string{ca1, ca1, ca2}},
+		{name: "missing path", value: filepath.Join(dir, "missing.pem"), expectErr: "stat " + filepath.Join(dir, "missing.pem")},
+		{name: "empty directory", value: emptyDir, expectErr: "directory " + emptyDir + " does not contain any .pem, .crt, .cer files"},
+		{name: "invalid file", value: invalid, expectErr: invalid + ": no PEM encoded certificates found"},
+		{name: "invalid inline", value: "-----BEGIN CERTIFICATE-----\ninvalid\n-----END CERTIFICATE-----", expectErr: "inline certificate: no PEM encoded certificates found"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cas, err := LoadCertificateAuthorities(tc.value)
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, cas)
+		})
+	}
+}
+
+func TestNewBindPlaneCertificateAuthority(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag": "v1.90.0"}`))
+	}))
+	defer server.Close()
+
+	serverCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	cases := []struct {
+		name      string
+		cas       []string
+		appendSys bool
+		expectErr bool
+	}{
+		{name: "server certificate authority", cas: []string{serverCA}},
+		{name: "multiple certificate authorities", cas: []string{testCertificate(t, "other"), serverCA}},
+		{name: "appended to system pool", cas: []string{serverCA}, appendSys: true},
+		{name: "unknown certificate authority", cas: []string{testCertificate(t, "other")}, expectErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewBindPlane(&config.Config{
+				Network: config.Network{
+					RemoteURL:            server.URL,
+					CertificateAuthority: tc.cas,
+					AppendSystemCertPool: tc.appendSys,
+				},
+			}, zap.NewNop())
+			require.NoError(t, err)
+
+			v, err := c.Version(context.Background())
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "v1.90.0", v.Tag)
+		})
+	}
+}
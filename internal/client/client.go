@@ -5,7 +5,9 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -13,11 +15,29 @@ import (
 	"github.com/observiq/bindplane-op-action/internal/client/config"
 	"github.com/observiq/bindplane-op-action/internal/client/model"
 	"github.com/observiq/bindplane-op-action/internal/client/version"
+	"github.com/observiq/bindplane-op-action/internal/proxy"
 
 	"github.com/go-resty/resty/v2"
 	"go.uber.org/zap"
 )
 
+// ErrNotFound is returned when a requested resource does not exist
+var ErrNotFound = errors.New("not found")
+
+// APIError is returned when the BindPlane API responds with an error status
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("BindPlane API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+func newAPIError(resp *resty.Response) error {
+	return &APIError{StatusCode: resp.StatusCode(), Body: resp.String()}
+}
+
 const (
 	KeyHeader = "X-Bindplane-Api-Key"
 
@@ -74,6 +94,13 @@ func NewBindPlane(config *config.Config, logger *zap.Logger) (*BindPlane, error)
 	}
 	if len(config.Network.CertificateAuthority) > 0 {
 		tlsConfig.RootCAs = x509.NewCertPool()
+		if config.Network.AppendSystemCertPool {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load system certificate pool: %w", err)
+			}
+			tlsConfig.RootCAs = pool
+		}
 		for _, ca := range config.Network.CertificateAuthority {
 			if ok := tlsConfig.RootCAs.AppendCertsFromPEM([]byte(ca)); !ok {
 				return nil, fmt.Errorf("failed to append certificate authority")
@@ -83,6 +110,45 @@ func NewBindPlane(config *config.Config, logger *zap.Logger) (*BindPlane, error)
 
 	restryClient.SetTLSClientConfig(tlsConfig)
 
+	transport, err := restryClient.Transport()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get http transport: %w", err)
+	}
+	transport.Proxy = proxy.Func(config.Network.Proxy)
+
+	if config.Auth.OIDC.TokenURL != "" {
+		tokens := newTokenSource(config.Auth.OIDC, transport)
+		restryClient.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+			token, err := tokens.Token()
+			if err != nil {
+				return err
+			}
+			r.SetAuthToken(token)
+			return nil
+		})
+
+		// Retry once with a fresh token when the access
+		// token is rejected before its expiry.
+		restryClient.SetRetryCount(1)
+		restryClient.AddRetryCondition(func(r *resty.Response, err error) bool {
+			if err != nil || r.StatusCode() != http.StatusUnauthorized {
+				return false
+			}
+			tokens.Invalidate()
+			return true
+		})
+	}
+
+	if config.Trace.Enabled || config.Trace.File != "" {
+		t := &tracer{
+			logger: logger,
+			log:    config.Trace.Enabled,
+			file:   config.Trace.File,
+			scrub:  config.Trace.Scrub,
+		}
+		t.register(restryClient)
+	}
+
 	return &BindPlane{
 		logger: logger,
 		config: config,
@@ -99,7 +165,7 @@ func (b *BindPlane) Version(_ context.Context) (version.Version, error) {
 	}
 
 	if r.StatusCode() != 200 {
-		return v, fmt.Errorf("failed to get version: %s", r.String())
+		return v, fmt.Errorf("failed to get version: %w", newAPIError(r))
 	}
 
 	return v, nil
@@ -124,7 +190,7 @@ func (c *BindPlane) Apply(_ context.Context, resources []*model.AnyResource) ([]
 
 	status := resp.StatusCode()
 	if status > 399 {
-		return nil, fmt.Errorf("BindPlane API returned status %d: %s", status, resp.String())
+		return nil, newAPIError(resp)
 	}
 
 	return ar.Updates, nil
@@ -133,13 +199,94 @@ func (c *BindPlane) Apply(_ context.Context, resources []*model.AnyResource) ([]
 // Configuration queries the BindPlane API and returns a configuration by name
 func (c *BindPlane) Configuration(_ context.Context, name string) (*model.Configuration, error) {
 	pr, err := c.configuration(name)
-	return pr.Configuration, err
+	if err != nil {
+		return nil, err
+	}
+	return pr.Configuration, nil
+}
+
+// Configurations queries the BindPlane API and returns all configurations
+func (c *BindPlane) Configurations(_ context.Context) ([]*model.Configuration, error) {
+	cr := &model.ConfigurationsResponse{}
+	resp, err := c.client.R().SetResult(cr).Get("/configurations")
+	if err != nil {
+		return nil, err
+	}
+
+	status := resp.StatusCode()
+	if status > 399 {
+		return nil, newAPIError(resp)
+	}
+
+	return cr.Configurations, nil
+}
+
+// Resource queries the BindPlane API and returns a resource of any kind by
+// name. ErrNotFound is returned when the resource does not exist.
+func (c *BindPlane) Resource(_ context.Context, kind model.Kind, name string) (*model.AnyResource, error) {
+	// Responses wrap the resource in a field named after its kind,
+	// such as {"destination": {...}}
+	kindName := strings.ToLower(string(kind))
+	body := map[string]json.RawMessage{}
+	resp, err := c.client.R().SetResult(&body).Get(fmt.Sprintf("/%ss/%s", kindName, name))
+	if err != nil {
+		return nil, err
+	}
+
+	status := resp.StatusCode()
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("%s %s: %w", kind, name, ErrNotFound)
+	}
+	if status > 399 {
+		return nil, newAPIError(resp)
+	}
+
+	raw, ok := body[kindName]
+	if !ok || string(raw) == "null" {
+		return nil, fmt.Errorf("%s %s: %w", kind, name, ErrNotFound)
+	}
+
+	r := &model.AnyResource{}
+	if err := json.Unmarshal(raw, r); err != nil {
+		return nil, fmt.Errorf("decode %s %s: %w", kind, name, err)
+	}
+	return r, nil
+}
+
+// Resources queries the BindPlane API and returns every resource of a kind
+func (c *BindPlane) Resources(_ context.Context, kind model.Kind) ([]*model.AnyResource, error) {
+	// Responses wrap the resources in a field named after the
+	// plural of their kind, such as {"destinations": [...]}
+	field := strings.ToLower(string(kind)) + "s"
+	body := map[string]json.RawMessage{}
+	resp, err := c.client.R().SetResult(&body).Get("/" + field)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() > 399 {
+		return nil, newAPIError(resp)
+	}
+
+	resources := []*model.AnyResource{}
+	raw, ok := body[field]
+	if !ok || string(raw) == "null" {
+		return resources, nil
+	}
+
+	if err := json.Unmarshal(raw, &resources); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", field, err)
+	}
+	return resources, nil
 }
 
 // RawConfiguration queries the BindPlane API and returns a raw configuration by name
 func (c *BindPlane) RawConfiguration(_ context.Context, name string) (string, error) {
 	pr, err := c.configuration(name)
-	return pr.Raw, err
+	if err != nil {
+		return "", err
+	}
+	return pr.Raw, nil
 }
 
 func (c *BindPlane) configuration(name string) (*model.ConfigurationResponse, error) {
@@ -150,8 +297,11 @@ func (c *BindPlane) configuration(name string) (*model.ConfigurationResponse, er
 	}
 
 	status := resp.StatusCode()
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("configuration %s: %w", name, ErrNotFound)
+	}
 	if status > 399 {
-		return nil, fmt.Errorf("BindPlane API returned status %d: %s", status, resp.String())
+		return nil, newAPIError(resp)
 	}
 
 	return pr, nil
@@ -176,7 +326,7 @@ func (c *BindPlane) StartRollout(name string) error {
 
 	status := resp.StatusCode()
 	if status > 399 {
-		return fmt.Errorf("BindPlane API returned status %d: %s", status, resp.String())
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -196,7 +346,7 @@ func (c *BindPlane) RolloutStatus(name string) (*model.Configuration, error) {
 
 	status := resp.StatusCode()
 	if status > 399 {
-		return nil, fmt.Errorf("BindPlane API returned status %d: %s", status, resp.String())
+		return nil, newAPIError(resp)
 	}
 
 	return response.Configuration, nil
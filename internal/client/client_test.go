@@ -1,7 +1,16 @@
 package client
 
 import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
 func TestBuildBaseURL(t *testing.T) {
@@ -106,3 +115,111 @@ func TestBuildBaseURL(t *testing.T) {
 		})
 	}
 }
+
+func TestNewBindPlaneProxy(t *testing.T) {
+	var proxied *http.Request
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag": "v1.90.0"}`))
+	}))
+	defer proxyServer.Close()
+
+	conf := &config.Config{
+		Network: config.Network{
+			RemoteURL: "http://bindplane.corp.net:3001",
+			Proxy: config.Proxy{
+				URL:      proxyServer.URL,
+				Username: "user",
+				Password: "pass",
+			},
+		},
+	}
+
+	c, err := NewBindPlane(conf, zap.NewNop())
+	require.NoError(t, err)
+
+	v, err := c.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "v1.90.0", v.Tag)
+
+	require.NotNil(t, proxied, "expected request to be sent through the proxy")
+	require.Equal(t, "http://bindplane.corp.net:3001/v1/version", proxied.RequestURI)
+
+	expectAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	require.Equal(t, expectAuth, proxied.Header.Get("Proxy-Authorization"))
+}
+
+func TestResource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/destinations/gateway":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"destination": {"kind": "Destination", "metadata": {"name": "gateway"}, "spec": {"type": "otlp"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{Network: config.Network{RemoteURL: server.URL}}, zap.NewNop())
+	require.NoError(t, err)
+
+	r, err := c.Resource(context.Background(), model.KindDestination, "gateway")
+	require.NoError(t, err)
+	require.Equal(t, "gateway", r.Metadata.Name)
+	require.Equal(t, "otlp", r.Spec["type"])
+
+	_, err = c.Resource(context.Background(), model.KindSource, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("invalid api key"))
+	}))
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{Network: config.Network{RemoteURL: server.URL}}, zap.NewNop())
+	require.NoError(t, err)
+
+	err = c.StartRollout("agents")
+	require.EqualError(t, err, "BindPlane API returned status 401: invalid api key")
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestResources(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/destinations":
+			_, _ = w.Write([]byte(`{"destinations": [{"kind": "Destination", "metadata": {"name": "gateway"}, "spec": {"type": "otlp"}}]}`))
+		case "/v1/sources":
+			_, _ = w.Write([]byte(`{"sources": null}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{Network: config.Network{RemoteURL: server.URL}}, zap.NewNop())
+	require.NoError(t, err)
+
+	resources, err := c.Resources(context.Background(), model.KindDestination)
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	require.Equal(t, "gateway", resources[0].Metadata.Name)
+
+	resources, err = c.Resources(context.Background(), model.KindSource)
+	require.NoError(t, err)
+	require.Empty(t, resources)
+
+	_, err = c.Resources(context.Background(), model.KindFleet)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+}
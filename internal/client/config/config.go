@@ -3,21 +3,57 @@ package config
 type Config struct {
 	Auth    Auth
 	Network Network
+	Trace   Trace
 }
 
 type Auth struct {
 	APIKey   string
 	Username string
 	Password string
+	OIDC     OIDC
+}
+
+// OIDC configures bearer token authentication. A GitHub Actions OIDC
+// token is exchanged for a BindPlane access token at TokenURL.
+type OIDC struct {
+	TokenURL string
+	Audience string
 }
 
 type Network struct {
 	RemoteURL            string
 	CertificateAuthority []string
-	UserAgent            string
+
+	// AppendSystemCertPool adds CertificateAuthority to the system
+	// certificate pool instead of replacing it
+	AppendSystemCertPool bool
+
+	UserAgent string
+	Proxy     Proxy
 	TLS
 }
 
 type TLS struct {
 	CertificateAuthority []string
 }
+
+// Proxy configures an explicit HTTP(S) proxy. When URL is empty, the
+// standard HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables
+// are used instead.
+type Proxy struct {
+	URL      string
+	Username string
+	Password string
+	NoProxy  string
+}
+
+// Trace configures request and response tracing. Traces are logged
+// when Enabled is true, and appended to File when it is set.
+type Trace struct {
+	Enabled bool
+	File    string
+
+	// Scrub removes secrets from trace file lines, if set. Logged
+	// traces are scrubbed by the logger's output instead.
+	Scrub func(string) string
+}
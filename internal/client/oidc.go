@@ -0,0 +1,170 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/observiq/bindplane-op-action/internal/client/config"
+)
+
+const (
+	// Environment variables set by GitHub when the workflow
+	// has the id-token: write permission.
+	envIDTokenRequestURL   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	envIDTokenRequestToken = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+
+	// RFC 8693 token exchange parameters
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeJWT           = "urn:ietf:params:oauth:token-type:jwt"
+
+	// tokenExpiryDelta is subtracted from the access token's lifetime
+	// so it is refreshed before the server rejects it.
+	tokenExpiryDelta = time.Second * 30
+)
+
+// idTokenResponse is returned by the GitHub Actions ID token endpoint
+type idTokenResponse struct {
+	Value string `json:"value"`
+}
+
+// tokenResponse is returned by the token exchange endpoint
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// tokenSource exchanges GitHub Actions OIDC tokens for BindPlane access
+// tokens. Access tokens are cached until they expire or are invalidated.
+type tokenSource struct {
+	config config.OIDC
+	client *resty.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func newTokenSource(c config.OIDC, transport http.RoundTripper) *tokenSource {
+	client := resty.NewWithClient(&http.Client{Transport: transport})
+	client.SetDisableWarn(true)
+	client.SetTimeout(DefaultTimeout)
+
+	return &tokenSource{
+		config: c,
+		client: client,
+	}
+}
+
+// Token returns a cached access token, or exchanges a new
+// OIDC token if there is no valid cached token.
+func (s *tokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && (s.expiry.IsZero() || time.Now().Before(s.expiry)) {
+		return s.token, nil
+	}
+
+	idToken, err := s.idToken()
+	if err != nil {
+		return "", fmt.Errorf("request github oidc token: %w", err)
+	}
+
+	token, expiresIn, err := s.exchange(idToken)
+	if err != nil {
+		return "", fmt.Errorf("exchange github oidc token: %w", err)
+	}
+
+	s.token = token
+	s.expiry = time.Time{}
+	if expiresIn > 0 {
+		s.expiry = time.Now().Add(time.Duration(expiresIn)*time.Second - tokenExpiryDelta)
+	}
+
+	return s.token, nil
+}
+
+// Invalidate clears the cached access token, forcing the
+// next call to Token to perform a new exchange.
+func (s *tokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+// idToken requests an OIDC token from the GitHub Actions runtime
+func (s *tokenSource) idToken() (string, error) {
+	requestURL := os.Getenv(envIDTokenRequestURL)
+	requestToken := os.Getenv(envIDTokenRequestToken)
+	if requestURL == "" || requestToken == "" {
+		return "", fmt.Errorf("%s and %s are not set, does the workflow have the id-token: write permission?", envIDTokenRequestURL, envIDTokenRequestToken)
+	}
+
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("parse %s: %w", envIDTokenRequestURL, err)
+	}
+	if s.config.Audience != "" {
+		q := u.Query()
+		q.Set("audience", s.config.Audience)
+		u.RawQuery = q.Encode()
+	}
+
+	result := &idTokenResponse{}
+	resp, err := s.client.R().
+		SetAuthToken(requestToken).
+		SetResult(result).
+		Get(u.String())
+	if err != nil {
+		return "", err
+	}
+
+	status := resp.StatusCode()
+	if status > 399 {
+		return "", fmt.Errorf("GitHub returned status %d: %s", status, resp.String())
+	}
+
+	if result.Value == "" {
+		return "", fmt.Errorf("GitHub returned an empty token")
+	}
+
+	return result.Value, nil
+}
+
+// exchange exchanges the OIDC token for an access token at the configured
+// token endpoint and returns the access token and its lifetime in seconds.
+func (s *tokenSource) exchange(idToken string) (string, int, error) {
+	form := map[string]string{
+		"grant_type":         tokenExchangeGrantType,
+		"subject_token":      idToken,
+		"subject_token_type": tokenTypeJWT,
+	}
+	if s.config.Audience != "" {
+		form["audience"] = s.config.Audience
+	}
+
+	result := &tokenResponse{}
+	resp, err := s.client.R().
+		SetFormData(form).
+		SetResult(result).
+		Post(s.config.TokenURL)
+	if err != nil {
+		return "", 0, err
+	}
+
+	status := resp.StatusCode()
+	if status > 399 {
+		return "", 0, fmt.Errorf("token endpoint returned status %d: %s", status, resp.String())
+	}
+
+	if result.AccessToken == "" {
+		return "", 0, fmt.Errorf("token endpoint returned an empty access token")
+	}
+
+	return result.AccessToken, result.ExpiresIn, nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewBindPlaneOIDC(t *testing.T) {
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer request-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("audience") != "bindplane" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"value": "github-id-token"}`))
+	}))
+	defer github.Close()
+
+	// The token endpoint issues a new access token for each exchange
+	var exchanges atomic.Int32
+	tokenEndpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("subject_token") != "github-id-token" ||
+			r.PostForm.Get("grant_type") != tokenExchangeGrantType {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n := exchanges.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "access-token-%d", "expires_in": 3600}`, n)
+	}))
+	defer tokenEndpoint.Close()
+
+	// BindPlane rejects the first access token to force a refresh
+	bindplane := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag": "v1.90.0"}`))
+	}))
+	defer bindplane.Close()
+
+	t.Setenv(envIDTokenRequestURL, github.URL+"/token?api-version=2.0")
+	t.Setenv(envIDTokenRequestToken, "request-token")
+
+	conf := &config.Config{
+		Auth: config.Auth{
+			OIDC: config.OIDC{
+				TokenURL: tokenEndpoint.URL,
+				Audience: "bindplane",
+			},
+		},
+		Network: config.Network{
+			RemoteURL: bindplane.URL,
+		},
+	}
+
+	c, err := NewBindPlane(conf, zap.NewNop())
+	require.NoError(t, err)
+
+	v, err := c.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "v1.90.0", v.Tag)
+	require.Equal(t, int32(2), exchanges.Load())
+
+	// The refreshed token is cached
+	_, err = c.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(2), exchanges.Load())
+}
+
+func TestNewBindPlaneOIDCMissingEnvironment(t *testing.T) {
+	t.Setenv(envIDTokenRequestURL, "")
+	t.Setenv(envIDTokenRequestToken, "")
+
+	conf := &config.Config{
+		Auth: config.Auth{
+			OIDC: config.OIDC{TokenURL: "http://localhost:1"},
+		},
+		Network: config.Network{
+			RemoteURL: "http://localhost:1",
+		},
+	}
+
+	c, err := NewBindPlane(conf, zap.NewNop())
+	require.NoError(t, err)
+
+	_, err = c.Version(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "id-token: write")
+}
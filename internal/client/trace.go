@@ -0,0 +1,228 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// redacted replaces secrets in traces
+const redacted = "[REDACTED]"
+
+// redactedHeaders are headers which carry credentials
+var redactedHeaders = []string{
+	KeyHeader,
+	"Authorization",
+	"Proxy-Authorization",
+}
+
+// trace is a single request and response to the BindPlane API
+type trace struct {
+	Time           time.Time   `json:"time"`
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	Status         int         `json:"status,omitempty"`
+	Latency        string      `json:"latency"`
+	RequestHeader  http.Header `json:"request_header,omitempty"`
+	RequestSize    int         `json:"request_size"`
+	RequestBody    string      `json:"request_body,omitempty"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+	ResponseSize   int         `json:"response_size"`
+	ResponseBody   string      `json:"response_body,omitempty"`
+	Error          string      `json:"error,omitempty"`
+}
+
+// tracer logs requests and responses with secrets redacted and
+// optionally appends them to a file as JSON lines.
+type tracer struct {
+	logger *zap.Logger
+	log    bool
+	file   string
+	mu     sync.Mutex
+
+	// scrub removes secrets from trace file lines, if set
+	scrub func(string) string
+}
+
+// register adds the tracer's hooks to the client
+func (t *tracer) register(c *resty.Client) {
+	c.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		t.record(resp.Request, resp, nil)
+		return nil
+	})
+	c.OnError(func(req *resty.Request, err error) {
+		var resp *resty.Response
+		if v, ok := err.(*resty.ResponseError); ok {
+			resp = v.Response
+			err = v.Err
+		}
+		t.record(req, resp, err)
+	})
+}
+
+func (t *tracer) record(req *resty.Request, resp *resty.Response, err error) {
+	requestBody := requestBodyBytes(req.Body)
+
+	tr := trace{
+		Time:          req.Time,
+		Method:        req.Method,
+		URL:           req.URL,
+		RequestHeader: redactHeader(req.Header),
+		RequestSize:   len(requestBody),
+		RequestBody:   string(redactBody(requestBody)),
+	}
+	if req.RawRequest != nil {
+		tr.URL = req.RawRequest.URL.String()
+		tr.RequestHeader = redactHeader(req.RawRequest.Header)
+	}
+
+	if resp != nil {
+		tr.Status = resp.StatusCode()
+		tr.Latency = resp.Time().String()
+		tr.ResponseHeader = redactHeader(resp.Header())
+		tr.ResponseSize = len(resp.Body())
+		tr.ResponseBody = string(redactBody(resp.Body()))
+	} else {
+		tr.Latency = time.Since(req.Time).String()
+	}
+
+	if err != nil {
+		tr.Error = err.Error()
+	}
+
+	if t.log {
+		t.logger.Info(
+			"BindPlane API trace",
+			zap.String("method", tr.Method),
+			zap.String("url", tr.URL),
+			zap.Int("status", tr.Status),
+			zap.String("latency", tr.Latency),
+			zap.Int("request_size", tr.RequestSize),
+			zap.Int("response_size", tr.ResponseSize),
+			zap.String("request_body", tr.RequestBody),
+			zap.String("response_body", tr.ResponseBody),
+			zap.String("error", tr.Error),
+		)
+	}
+
+	if t.file == "" {
+		return
+	}
+
+	if err := t.write(tr); err != nil {
+		t.logger.Warn("Failed to write trace file", zap.String("path", t.file), zap.Error(err))
+	}
+}
+
+// write appends the trace to the trace file as a JSON line
+func (t *tracer) write(tr trace) error {
+	b, err := json.Marshal(tr)
+	if err != nil {
+		return fmt.Errorf("marshal trace: %w", err)
+	}
+	if t.scrub != nil {
+		b = []byte(t.scrub(string(b)))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, err := os.OpenFile(t.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 user defined filepath
+	if err != nil {
+		return fmt.Errorf("open trace file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("write trace file: %w", err)
+	}
+
+	return nil
+}
+
+// requestBodyBytes returns the request body as it is sent by resty
+func requestBodyBytes(body any) []byte {
+	switch b := body.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return b
+	case string:
+		return []byte(b)
+	default:
+		out, err := json.Marshal(b)
+		if err != nil {
+			return nil
+		}
+		return out
+	}
+}
+
+// redactHeader returns a copy of the header with credentials redacted
+func redactHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+
+	out := h.Clone()
+	for _, name := range redactedHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// redactBody redacts the values of parameters marked sensitive and
+// rendered configurations in a JSON body. Bodies which are not JSON
+// are returned unmodified.
+func redactBody(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+
+	out, err := json.Marshal(redactSensitive(v))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// redactSensitive walks a decoded JSON value and replaces the value
+// of any object which has "sensitive": true, matching model.Parameter,
+// and raw configurations, which contain the secrets of destinations.
+func redactSensitive(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		if sensitive, ok := t["sensitive"].(bool); ok && sensitive {
+			if _, ok := t["value"]; ok {
+				t["value"] = redacted
+			}
+		}
+		if raw, ok := t["raw"].(string); ok && raw != "" {
+			t["raw"] = redacted
+		}
+		for k, child := range t {
+			t[k] = redactSensitive(child)
+		}
+		return t
+	case []any:
+		for i, child := range t {
+			t[i] = redactSensitive(child)
+		}
+		return t
+	default:
+		return v
+	}
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"github.com/observiq/bindplane-op-action/internal/logging"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactBody(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			"Sensitive parameter",
+			`{"parameters":[{"name":"api_key","value":"secret","sensitive":true},{"name":"endpoint","value":"otlp"}]}`,
+			`{"parameters":[{"name":"api_key","sensitive":true,"value":"[REDACTED]"},{"name":"endpoint","value":"otlp"}]}`,
+		},
+		{
+			"Rendered configuration",
+			`{"configuration":{"spec":{"raw":""}},"raw":"exporters:\n  otlp:\n    api_key: secret\n"}`,
+			`{"configuration":{"spec":{"raw":""}},"raw":"[REDACTED]"}`,
+		},
+		{
+			"Not JSON",
+			"BindPlane API error",
+			"BindPlane API error",
+		},
+		{
+			"Empty",
+			"",
+			"",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expect, string(redactBody([]byte(tc.input))))
+		})
+	}
+}
+
+func TestNewBindPlaneTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors": ["invalid resource"]}`))
+	}))
+	defer server.Close()
+
+	core, logs := observer.New(zap.InfoLevel)
+	traceFile := filepath.Join(t.TempDir(), "trace.jsonl")
+
+	conf := &config.Config{
+		Auth: config.Auth{
+			APIKey: "super-secret-key",
+		},
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+		Trace: config.Trace{
+			Enabled: true,
+			File:    traceFile,
+		},
+	}
+
+	c, err := NewBindPlane(conf, zap.New(core))
+	require.NoError(t, err)
+
+	resources := []*model.AnyResource{
+		{
+			ResourceMeta: model.ResourceMeta{Kind: "Destination"},
+			Spec: map[string]any{
+				"parameters": []any{
+					map[string]any{"name": "api_key", "value": "destination-secret", "sensitive": true},
+				},
+			},
+		},
+	}
+	_, err = c.Apply(context.Background(), resources)
+	require.Error(t, err)
+
+	entries := logs.FilterMessage("BindPlane API trace").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	require.Equal(t, "POST", fields["method"])
+	require.Equal(t, int64(http.StatusBadRequest), fields["status"])
+	require.Contains(t, fields["response_body"], "invalid resource")
+	require.NotContains(t, fields["request_body"], "destination-secret")
+
+	f, err := os.Open(traceFile) // #nosec G304 test file
+	require.NoError(t, err)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	require.True(t, scanner.Scan())
+	line := scanner.Text()
+	require.NotContains(t, line, "super-secret-key")
+	require.NotContains(t, line, "destination-secret")
+
+	tr := trace{}
+	require.NoError(t, json.Unmarshal([]byte(line), &tr))
+	require.Equal(t, redacted, tr.RequestHeader.Get(KeyHeader))
+	require.True(t, strings.HasSuffix(tr.URL, "/v1/apply"))
+	require.False(t, scanner.Scan(), "expected a single trace")
+}
+
+func TestNewBindPlaneTraceConfiguration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"configuration": {"metadata": {"name": "gateway"}, "spec": {"contentType": "text/yaml"}},
+			"raw": "exporters:\n  otlp:\n    headers:\n      x-api-key: destination-secret\n"
+		}`))
+	}))
+	defer server.Close()
+
+	masker := logging.NewMasker(nil)
+	masker.Add("gateway-password")
+
+	core, logs := observer.New(zap.InfoLevel)
+	traceFile := filepath.Join(t.TempDir(), "trace.jsonl")
+
+	conf := &config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+		Trace: config.Trace{
+			Enabled: true,
+			File:    traceFile,
+			Scrub:   masker.Scrub,
+		},
+	}
+
+	c, err := NewBindPlane(conf, zap.New(core))
+	require.NoError(t, err)
+
+	// The masked value only appears in the URL
+	raw, err := c.RawConfiguration(context.Background(), "gateway-password")
+	require.NoError(t, err)
+	require.Contains(t, raw, "destination-secret", "the raw configuration is only redacted in traces")
+
+	entries := logs.FilterMessage("BindPlane API trace").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	require.NotContains(t, fields["response_body"], "destination-secret")
+
+	b, err := os.ReadFile(traceFile) // #nosec G304 test file
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "destination-secret")
+	require.NotContains(t, string(b), "gateway-password")
+	require.Contains(t, string(b), logging.Mask)
+}
@@ -1,6 +1,96 @@
 package version
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 type Version struct {
 	Commit string `json:"commit"`
 	Tag    string `json:"tag"`
 }
+
+// Semantic is a parsed major.minor.patch version
+type Semantic struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseSemantic parses a version tag such as v1.2.3 or 1.2.3-rc.1.
+// Pre-release and build metadata suffixes are ignored.
+func ParseSemantic(tag string) (Semantic, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(tag), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Semantic{}, fmt.Errorf("version %q is not of the form major.minor.patch", tag)
+	}
+
+	nums := [3]int{}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Semantic{}, fmt.Errorf("version %q is not of the form major.minor.patch", tag)
+		}
+		nums[i] = n
+	}
+
+	return Semantic{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+// AtLeast returns true if s is greater than or equal to o
+func (s Semantic) AtLeast(o Semantic) bool {
+	if s.Major != o.Major {
+		return s.Major > o.Major
+	}
+	if s.Minor != o.Minor {
+		return s.Minor > o.Minor
+	}
+	return s.Patch >= o.Patch
+}
+
+func (s Semantic) String() string {
+	return fmt.Sprintf("v%d.%d.%d", s.Major, s.Minor, s.Patch)
+}
+
+// Feature is a capability of the BindPlane API that is
+// not available in every supported server version.
+type Feature string
+
+const (
+	FeatureRollouts   Feature = "rollouts"
+	FeatureConnectors Feature = "connectors"
+	FeatureFleets     Feature = "fleets"
+)
+
+// Minimums is the compatibility matrix of features and the minimum server
+// version which supports them. Versions match the oldest releases verified
+// by the action's CI workflow. Every version supporting rollouts uses the
+// same rollout endpoints, so the client does not select them by version.
+var Minimums = map[Feature]Semantic{
+	FeatureRollouts:   {Major: 1, Minor: 55, Patch: 0},
+	FeatureConnectors: {Major: 1, Minor: 83, Patch: 0},
+	FeatureFleets:     {Major: 1, Minor: 91, Patch: 0},
+}
+
+// Supports returns true if the version supports the feature. The second
+// return value is false when the version tag could not be parsed, such
+// as with development builds, in which case support is assumed.
+func (v Version) Supports(f Feature) (bool, bool) {
+	s, err := ParseSemantic(v.Tag)
+	if err != nil {
+		return true, false
+	}
+
+	minimum, ok := Minimums[f]
+	if !ok {
+		return true, true
+	}
+
+	return s.AtLeast(minimum), true
+}
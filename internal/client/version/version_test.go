@@ -0,0 +1,60 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSemantic(t *testing.T) {
+	cases := []struct {
+		tag    string
+		expect Semantic
+		err    bool
+	}{
+		{"v1.91.0", Semantic{1, 91, 0}, false},
+		{"1.59.2", Semantic{1, 59, 2}, false},
+		{"v1.92.0-rc.1", Semantic{1, 92, 0}, false},
+		{"v1.92.0+abc", Semantic{1, 92, 0}, false},
+		{"", Semantic{}, true},
+		{"latest", Semantic{}, true},
+		{"v1.2", Semantic{}, true},
+		{"v1.x.0", Semantic{}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.tag, func(t *testing.T) {
+			s, err := ParseSemantic(tc.tag)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, s)
+		})
+	}
+}
+
+func TestSupports(t *testing.T) {
+	cases := []struct {
+		name      string
+		tag       string
+		feature   Feature
+		supported bool
+		known     bool
+	}{
+		{"connectors unsupported", "v1.59.0", FeatureConnectors, false, true},
+		{"connectors supported", "v1.83.0", FeatureConnectors, true, true},
+		{"fleets unsupported", "v1.83.0", FeatureFleets, false, true},
+		{"fleets supported", "v2.0.0", FeatureFleets, true, true},
+		{"unknown version", "dev", FeatureFleets, true, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			supported, known := Version{Tag: tc.tag}.Supports(tc.feature)
+			require.Equal(t, tc.supported, supported)
+			require.Equal(t, tc.known, known)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Package github is a minimal client for the GitHub REST API
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"github.com/observiq/bindplane-op-action/internal/proxy"
+)
+
+// DefaultAPIURL is used when an API URL is not configured and
+// GITHUB_API_URL is not set by the runner.
+const DefaultAPIURL = "https://api.github.com"
+
+// PullRequest is a GitHub pull request
+type PullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+}
+
+// NewPullRequest is the payload used to create a pull request
+type NewPullRequest struct {
+	Title string `json:"title"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Body  string `json:"body"`
+}
+
+// updatePullRequest is the payload used to update a pull request
+type updatePullRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Client is a GitHub REST API client
+type Client struct {
+	client *resty.Client
+}
+
+// APIURL returns apiURL, or GITHUB_API_URL when apiURL is empty,
+// falling back to DefaultAPIURL.
+func APIURL(apiURL string) string {
+	if apiURL != "" {
+		return apiURL
+	}
+	if u := os.Getenv("GITHUB_API_URL"); u != "" {
+		return u
+	}
+	return DefaultAPIURL
+}
+
+// New returns a client for the GitHub API at apiURL which authenticates
+// with token. Requests are sent through the proxy when configured.
+func New(apiURL, token string, p config.Proxy) (*Client, error) {
+	c := resty.New()
+	c.SetDisableWarn(true)
+	c.SetBaseURL(strings.TrimRight(APIURL(apiURL), "/"))
+	c.SetAuthToken(token)
+	c.SetHeader("Accept", "application/vnd.github+json")
+	c.SetHeader("X-GitHub-Api-Version", "2022-11-28")
+
+	transport, err := c.Transport()
+	if err != nil {
+		return nil, fmt.Errorf("get http transport: %w", err)
+	}
+	transport.Proxy = proxy.Func(p)
+
+	return &Client{client: c}, nil
+}
+
+// FindPullRequest returns the open pull request from head into base,
+// or nil if one does not exist.
+func (c *Client) FindPullRequest(ctx context.Context, owner, repo, head, base string) (*PullRequest, error) {
+	prs := []PullRequest{}
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{
+			"state": "open",
+			"head":  fmt.Sprintf("%s:%s", owner, head),
+			"base":  base,
+		}).
+		SetResult(&prs).
+		Get(fmt.Sprintf("/repos/%s/%s/pulls", url.PathEscape(owner), url.PathEscape(repo)))
+	if err != nil {
+		return nil, fmt.Errorf("list pull requests: %w", err)
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return nil, fmt.Errorf("GitHub API returned status %d: %s", status, resp.String())
+	}
+
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return &prs[0], nil
+}
+
+// CreatePullRequest opens a pull request
+func (c *Client) CreatePullRequest(ctx context.Context, owner, repo string, pr NewPullRequest) (*PullRequest, error) {
+	created := &PullRequest{}
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetBody(pr).
+		SetResult(created).
+		Post(fmt.Sprintf("/repos/%s/%s/pulls", url.PathEscape(owner), url.PathEscape(repo)))
+	if err != nil {
+		return nil, fmt.Errorf("create pull request: %w", err)
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return nil, fmt.Errorf("GitHub API returned status %d: %s", status, resp.String())
+	}
+
+	return created, nil
+}
+
+// UpdatePullRequest updates the title and body of a pull request
+func (c *Client) UpdatePullRequest(ctx context.Context, owner, repo string, number int, title, body string) (*PullRequest, error) {
+	updated := &PullRequest{}
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetBody(updatePullRequest{Title: title, Body: body}).
+		SetResult(updated).
+		Patch(fmt.Sprintf("/repos/%s/%s/pulls/%d", url.PathEscape(owner), url.PathEscape(repo), number))
+	if err != nil {
+		return nil, fmt.Errorf("update pull request: %w", err)
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return nil, fmt.Errorf("GitHub API returned status %d: %s", status, resp.String())
+	}
+
+	return updated, nil
+}
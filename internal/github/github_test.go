@@ -0,0 +1,87 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIURL(t *testing.T) {
+	t.Setenv("GITHUB_API_URL", "")
+	require.Equal(t, DefaultAPIURL, APIURL(""))
+
+	t.Setenv("GITHUB_API_URL", "https://ghe.corp.net/api/v3")
+	require.Equal(t, "https://ghe.corp.net/api/v3", APIURL(""))
+	require.Equal(t, "https://api.corp.net", APIURL("https://api.corp.net"))
+}
+
+func TestPullRequests(t *testing.T) {
+	var created NewPullRequest
+	var updated updatePullRequest
+
+	mux := http.NewServeMux()
+	withJSON := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			h(w, r)
+		}
+	}
+	mux.HandleFunc("GET /repos/observIQ/configs/pulls", withJSON(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.Equal(t, "open", r.URL.Query().Get("state"))
+		require.Equal(t, "main", r.URL.Query().Get("base"))
+
+		prs := []PullRequest{}
+		if r.URL.Query().Get("head") == "observIQ:existing" {
+			prs = append(prs, PullRequest{Number: 7, HTMLURL: "https://github.com/observIQ/configs/pull/7"})
+		}
+		_ = json.NewEncoder(w).Encode(prs)
+	}))
+	mux.HandleFunc("POST /repos/observIQ/configs/pulls", withJSON(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(PullRequest{Number: 8, Title: created.Title, Body: created.Body})
+	}))
+	mux.HandleFunc("PATCH /repos/observIQ/configs/pulls/7", withJSON(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&updated))
+		_ = json.NewEncoder(w).Encode(PullRequest{Number: 7, Title: updated.Title, Body: updated.Body})
+	}))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := New(server.URL, "token", config.Proxy{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	pr, err := c.FindPullRequest(ctx, "observIQ", "configs", "missing", "main")
+	require.NoError(t, err)
+	require.Nil(t, pr)
+
+	pr, err = c.FindPullRequest(ctx, "observIQ", "configs", "existing", "main")
+	require.NoError(t, err)
+	require.Equal(t, 7, pr.Number)
+
+	pr, err = c.CreatePullRequest(ctx, "observIQ", "configs", NewPullRequest{
+		Title: "title",
+		Head:  "new",
+		Base:  "main",
+		Body:  "body",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 8, pr.Number)
+	require.Equal(t, NewPullRequest{Title: "title", Head: "new", Base: "main", Body: "body"}, created)
+
+	pr, err = c.UpdatePullRequest(ctx, "observIQ", "configs", 7, "new title", "new body")
+	require.NoError(t, err)
+	require.Equal(t, "new body", pr.Body)
+	require.Equal(t, updatePullRequest{Title: "new title", Body: "new body"}, updated)
+
+	_, err = c.UpdatePullRequest(ctx, "observIQ", "configs", 9, "title", "body")
+	require.Error(t, err)
+}
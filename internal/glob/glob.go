@@ -1,8 +1,32 @@
 package glob
 
-import "strings"
+import (
+	"path"
+	"strings"
+)
 
 // ContainsGlobChars checks if a path contains glob pattern characters
 func ContainsGlobChars(path string) bool {
 	return strings.ContainsAny(path, "*?[")
 }
+
+// MatchBest returns the pattern which best matches name. Patterns use
+// path.Match syntax, so * does not match a /. A pattern equal to name is
+// preferred, followed by the longest matching pattern.
+func MatchBest(patterns []string, name string) (string, bool) {
+	best, found := "", false
+	for _, p := range patterns {
+		if p == name {
+			return p, true
+		}
+
+		ok, err := path.Match(p, name)
+		if err != nil || !ok {
+			continue
+		}
+		if !found || len(p) > len(best) || (len(p) == len(best) && p < best) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
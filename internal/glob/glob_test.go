@@ -99,3 +99,60 @@ func TestContainsGlobChars(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchBest(t *testing.T) {
+	patterns := []string{"main", "release/*", "release/1.*", "*", "feature-[0-9]*"}
+
+	tests := []struct {
+		name          string
+		input         string
+		expected      string
+		expectedFound bool
+	}{
+		{
+			name:          "exact match",
+			input:         "main",
+			expected:      "main",
+			expectedFound: true,
+		},
+		{
+			name:          "longest pattern",
+			input:         "release/1.0",
+			expected:      "release/1.*",
+			expectedFound: true,
+		},
+		{
+			name:          "pattern",
+			input:         "release/2.0",
+			expected:      "release/*",
+			expectedFound: true,
+		},
+		{
+			name:          "wildcard",
+			input:         "develop",
+			expected:      "*",
+			expectedFound: true,
+		},
+		{
+			name:          "wildcard does not match slash",
+			input:         "feature/login",
+			expected:      "",
+			expectedFound: false,
+		},
+		{
+			name:          "character class",
+			input:         "feature-42",
+			expected:      "feature-[0-9]*",
+			expectedFound: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, found := MatchBest(patterns, tt.input)
+			if result != tt.expected || found != tt.expectedFound {
+				t.Errorf("MatchBest(%q) = %q, %v, expected %q, %v", tt.input, result, found, tt.expected, tt.expectedFound)
+			}
+		})
+	}
+}
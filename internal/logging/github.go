@@ -0,0 +1,198 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// FileKey is the field key of the file a log is about. Errors and
+// warnings with this field annotate the file in the GitHub UI.
+const FileKey = "resource_path"
+
+// fileError is an error about a file, such as an invalid resource file
+type fileError interface {
+	error
+	File() string
+}
+
+// githubCore is a zapcore.Core which writes GitHub Actions workflow
+// commands. See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+type githubCore struct {
+	zapcore.LevelEnabler
+
+	enc       zapcore.Encoder
+	out       zapcore.WriteSyncer
+	workspace string
+
+	// group and file are set from fields added with With
+	group string
+	file  string
+
+	// state is shared by cores created with With, because groups
+	// are sections of the output rather than of a single logger
+	state *groupState
+}
+
+type groupState struct {
+	mu    sync.Mutex
+	group string
+}
+
+func newGitHubCore(enab zapcore.LevelEnabler, out zapcore.WriteSyncer, workspace string) *githubCore {
+	// The workflow command conveys the level and the
+	// runner timestamps each line, so only the message
+	// and fields are encoded
+	enc := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+		MessageKey:       "msg",
+		LineEnding:       zapcore.DefaultLineEnding,
+		EncodeDuration:   zapcore.StringDurationEncoder,
+		ConsoleSeparator: " ",
+	})
+
+	return &githubCore{
+		LevelEnabler: enab,
+		enc:          enc,
+		out:          out,
+		workspace:    workspace,
+		state:        &groupState{},
+	}
+}
+
+// With returns a copy of the core with the fields added
+func (c *githubCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.enc = c.enc.Clone()
+	clone.group, clone.file = c.fields(fields, c.group, c.file)
+	for _, f := range fields {
+		if f.Key != GroupKey {
+			f.AddTo(clone.enc)
+		}
+	}
+	return &clone
+}
+
+// Check adds the core to the checked entry when the level is enabled
+func (c *githubCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write writes the entry, starting or ending a group when
+// the group of the entry differs from the current group
+func (c *githubCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	group, file := c.fields(fields, c.group, c.file)
+
+	encoded := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		if f.Key != GroupKey {
+			encoded = append(encoded, f)
+		}
+	}
+
+	buf, err := c.enc.EncodeEntry(ent, encoded)
+	if err != nil {
+		return err
+	}
+	line := strings.TrimSuffix(buf.String(), zapcore.DefaultLineEnding)
+	buf.Free()
+
+	b := strings.Builder{}
+
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	if group != c.state.group {
+		if c.state.group != "" {
+			b.WriteString("::endgroup::\n")
+		}
+		if group != "" {
+			fmt.Fprintf(&b, "::group::%s\n", escapeData(group))
+		}
+		c.state.group = group
+	}
+
+	switch {
+	case ent.Level >= zapcore.ErrorLevel:
+		b.WriteString(command("error", c.relative(file), line))
+	case ent.Level == zapcore.WarnLevel:
+		b.WriteString(command("warning", c.relative(file), line))
+	case ent.Level == zapcore.DebugLevel:
+		b.WriteString(command("debug", "", line))
+	default:
+		b.WriteString(line)
+	}
+	b.WriteString("\n")
+
+	_, err = c.out.Write([]byte(b.String()))
+	return err
+}
+
+// Sync flushes the output
+func (c *githubCore) Sync() error {
+	return c.out.Sync()
+}
+
+// fields returns the group and file of the fields, defaulting
+// to the given values. The file is read from the file field,
+// or from an error about a file.
+func (c *githubCore) fields(fields []zapcore.Field, group, file string) (string, string) {
+	for _, f := range fields {
+		switch {
+		case f.Key == GroupKey && f.Type == zapcore.StringType:
+			group = f.String
+		case f.Key == FileKey && f.Type == zapcore.StringType:
+			file = f.String
+		case f.Type == zapcore.ErrorType && file == "":
+			err, ok := f.Interface.(error)
+			if !ok {
+				continue
+			}
+			var fe fileError
+			if errors.As(err, &fe) {
+				file = fe.File()
+			}
+		}
+	}
+	return group, file
+}
+
+// relative returns the path of a file relative to the workspace,
+// which is how GitHub expects annotated files to be named
+func (c *githubCore) relative(file string) string {
+	if c.workspace == "" || !filepath.IsAbs(file) {
+		return file
+	}
+	rel, err := filepath.Rel(c.workspace, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return file
+	}
+	return rel
+}
+
+// command returns a workflow command, such as ::error file=app.yaml::message
+func command(name, file, message string) string {
+	props := ""
+	if file != "" {
+		props = " file=" + escapeProperty(file)
+	}
+	return fmt.Sprintf("::%s%s::%s", name, props, escapeData(message))
+}
+
+var dataReplacer = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+var propertyReplacer = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+func escapeData(s string) string {
+	return dataReplacer.Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return propertyReplacer.Replace(s)
+}
@@ -0,0 +1,78 @@
+// Package logging builds the logger used by the action
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// FormatJSON logs JSON lines
+	FormatJSON = "json"
+
+	// FormatConsole logs human readable lines
+	FormatConsole = "console"
+
+	// FormatGitHub logs human readable lines, with errors and warnings
+	// written as GitHub Actions workflow commands so they are shown as
+	// annotations, and grouped logs written as collapsible sections
+	FormatGitHub = "github"
+)
+
+// Formats are the supported log formats
+var Formats = []string{FormatJSON, FormatConsole, FormatGitHub}
+
+// Levels are the supported log levels
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// GroupKey is the field key used to group logs
+const GroupKey = "group"
+
+// Group returns a field which places a log in a named group. Loggers
+// created with With(Group(name)) log each entry in the group.
+func Group(name string) zap.Field {
+	return zap.String(GroupKey, name)
+}
+
+// New returns a logger which writes to w at the given level and format
+func New(level, format string, w zapcore.WriteSyncer) (*zap.Logger, error) {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	var core zapcore.Core
+	switch format {
+	case FormatJSON:
+		encoderConf := zap.NewProductionEncoderConfig()
+		encoderConf.TimeKey = "time"
+		encoderConf.EncodeTime = zapcore.ISO8601TimeEncoder
+		core = zapcore.NewCore(zapcore.NewJSONEncoder(encoderConf), w, lvl)
+	case FormatConsole:
+		encoderConf := zap.NewDevelopmentEncoderConfig()
+		encoderConf.NameKey = ""
+		encoderConf.CallerKey = ""
+		encoderConf.StacktraceKey = ""
+		encoderConf.EncodeTime = zapcore.ISO8601TimeEncoder
+		core = zapcore.NewCore(zapcore.NewConsoleEncoder(encoderConf), w, lvl)
+	case FormatGitHub:
+		core = newGitHubCore(lvl, w, os.Getenv("GITHUB_WORKSPACE"))
+	default:
+		return nil, fmt.Errorf("log_format must be one of %s", strings.Join(Formats, ", "))
+	}
+
+	return zap.New(core), nil
+}
+
+func parseLevel(level string) (zapcore.Level, error) {
+	for _, l := range Levels {
+		if level == l {
+			return zapcore.ParseLevel(level)
+		}
+	}
+	return zapcore.InfoLevel, fmt.Errorf("log_level must be one of %s", strings.Join(Levels, ", "))
+}
@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testFileError struct {
+	file string
+}
+
+func (e testFileError) Error() string { return "invalid resource" }
+
+func (e testFileError) File() string { return e.file }
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		level     string
+		format    string
+		expectErr string
+	}{
+		{name: "json", level: "info", format: FormatJSON},
+		{name: "console", level: "debug", format: FormatConsole},
+		{name: "github", level: "warn", format: FormatGitHub},
+		{name: "invalid level", level: "trace", format: FormatJSON, expectErr: "log_level must be one of debug, info, warn, error"},
+		{name: "invalid format", level: "info", format: "text", expectErr: "log_format must be one of json, console, github"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			logger, err := New(tc.level, tc.format, zapcore.AddSync(&bytes.Buffer{}))
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, logger)
+		})
+	}
+}
+
+func TestGitHubFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		workspace string
+		log       func(logger *zap.Logger)
+		expected  string
+	}{
+		{
+			name: "info",
+			log: func(logger *zap.Logger) {
+				logger.Info("Applied resource", zap.String("name", "otlp"))
+			},
+			expected: "Applied resource {\"name\": \"otlp\"}\n",
+		},
+		{
+			name: "debug",
+			log: func(logger *zap.Logger) {
+				logger.Debug("Loaded resource")
+			},
+			expected: "::debug::Loaded resource\n",
+		},
+		{
+			name: "warning",
+			log: func(logger *zap.Logger) {
+				logger.Warn("Version unknown")
+			},
+			expected: "::warning::Version unknown\n",
+		},
+		{
+			name: "error with file field",
+			log: func(logger *zap.Logger) {
+				logger.Error("Failed", zap.String(FileKey, "resources/a,b.yaml"))
+			},
+			expected: "::error file=resources/a%2Cb.yaml::Failed {\"resource_path\": \"resources/a,b.yaml\"}\n",
+		},
+		{
+			name:      "error about a file",
+			workspace: "/workspace",
+			log: func(logger *zap.Logger) {
+				err := fmt.Errorf("destinations: %w", testFileError{file: "/workspace/resources/dest.yaml"})
+				logger.Error("error running action", zap.Error(err))
+			},
+			expected: "::error file=resources/dest.yaml::error running action {\"error\": \"destinations: invalid resource\"}\n",
+		},
+		{
+			name: "error without a file",
+			log: func(logger *zap.Logger) {
+				logger.Error("multi\nline", zap.Error(errors.New("100%")))
+			},
+			expected: "::error::multi%0Aline {\"error\": \"100%25\"}\n",
+		},
+		{
+			name: "groups",
+			log: func(logger *zap.Logger) {
+				logger.Info("Applying resources to Bindplane")
+				logger.With(Group("destinations")).Info("Applying resources")
+				sources := logger.With(Group("sources"))
+				sources.Info("Applying resources")
+				sources.Info("Applied resource")
+				logger.Info("Done")
+			},
+			expected: "Applying resources to Bindplane\n" +
+				"::group::destinations\nApplying resources\n" +
+				"::endgroup::\n::group::sources\nApplying resources\nApplied resource\n" +
+				"::endgroup::\nDone\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			logger := zap.New(newGitHubCore(zapcore.DebugLevel, zapcore.AddSync(buf), tc.workspace))
+			tc.log(logger)
+			require.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Mask replaces secrets scrubbed from logs
+const Mask = "***"
+
+// minSecretLength is the shortest secret which is masked. Shorter values
+// are too likely to appear in unrelated text, such as log messages.
+const minSecretLength = 4
+
+// Masker holds secrets which must not appear in logs. In GitHub Actions,
+// each secret is registered with the add-mask workflow command so the
+// runner masks it in every log line, including those of later steps.
+// Secrets are also scrubbed from logs written through Writer, which
+// protects runs outside of GitHub Actions. A nil Masker does nothing.
+type Masker struct {
+	mu sync.RWMutex
+
+	// commands receives add-mask workflow commands, if set
+	commands io.Writer
+
+	secrets  map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// NewMasker returns a Masker which writes add-mask workflow commands to
+// commands. Commands should only be written in GitHub Actions, outside
+// of it they would print the secrets, so commands may be nil.
+func NewMasker(commands io.Writer) *Masker {
+	return &Masker{
+		commands: commands,
+		secrets:  map[string]struct{}{},
+		replacer: strings.NewReplacer(),
+	}
+}
+
+// Add registers secrets. Each line of a multi-line secret, such as a
+// private key, is registered separately because the runner masks lines.
+func (m *Masker) Add(secrets ...string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	added := false
+	for _, secret := range secrets {
+		for _, line := range strings.Split(secret, "\n") {
+			line = strings.TrimSpace(line)
+			if len(line) < minSecretLength {
+				continue
+			}
+			if _, ok := m.secrets[line]; ok {
+				continue
+			}
+
+			m.secrets[line] = struct{}{}
+			added = true
+			if m.commands != nil {
+				fmt.Fprintf(m.commands, "::add-mask::%s\n", escapeData(line))
+			}
+		}
+	}
+
+	if added {
+		m.replacer = newSecretReplacer(m.secrets)
+	}
+}
+
+// Scrub returns s with every secret replaced by Mask
+func (m *Masker) Scrub(s string) string {
+	if m == nil {
+		return s
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.replacer.Replace(s)
+}
+
+// Writer returns a WriteSyncer which scrubs secrets before writing to w
+func (m *Masker) Writer(w zapcore.WriteSyncer) zapcore.WriteSyncer {
+	return &scrubWriter{masker: m, out: w}
+}
+
+type scrubWriter struct {
+	masker *Masker
+	out    zapcore.WriteSyncer
+}
+
+func (w *scrubWriter) Write(p []byte) (int, error) {
+	if _, err := w.out.Write([]byte(w.masker.Scrub(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *scrubWriter) Sync() error {
+	return w.out.Sync()
+}
+
+// newSecretReplacer returns a replacer of the secrets and their JSON
+// escaped forms, which appear in JSON logs. Longer secrets are replaced
+// first so a secret containing another is fully masked.
+func newSecretReplacer(secrets map[string]struct{}) *strings.Replacer {
+	forms := make([]string, 0, len(secrets))
+	for secret := range secrets {
+		forms = append(forms, secret)
+		if escaped := jsonEscape(secret); escaped != secret {
+			forms = append(forms, escaped)
+		}
+	}
+
+	sort.Slice(forms, func(i, j int) bool {
+		if len(forms[i]) != len(forms[j]) {
+			return len(forms[i]) > len(forms[j])
+		}
+		return forms[i] < forms[j]
+	})
+
+	oldnew := make([]string, 0, len(forms)*2)
+	for _, f := range forms {
+		oldnew = append(oldnew, f, Mask)
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+// jsonEscape returns s as it appears within a JSON string
+func jsonEscape(s string) string {
+	b := bytes.Buffer{}
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return s
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(b.String()), `"`), `"`)
+}
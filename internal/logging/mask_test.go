@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMasker(t *testing.T) {
+	tests := []struct {
+		name           string
+		secrets        []string
+		input          string
+		expected       string
+		expectCommands string
+	}{
+		{
+			name:           "secret",
+			secrets:        []string{"s3cr3t-key"},
+			input:          "api key s3cr3t-key rejected",
+			expected:       "api key *** rejected",
+			expectCommands: "::add-mask::s3cr3t-key\n",
+		},
+		{
+			name:           "empty and short values are ignored",
+			secrets:        []string{"", "abc"},
+			input:          "abc",
+			expected:       "abc",
+			expectCommands: "",
+		},
+		{
+			name:           "duplicates are registered once",
+			secrets:        []string{"password", "password"},
+			input:          "password",
+			expected:       "***",
+			expectCommands: "::add-mask::password\n",
+		},
+		{
+			name:           "multi-line secret is registered per line",
+			secrets:        []string{"-----BEGIN KEY-----\nAAAABBBB\n-----END KEY-----\n"},
+			input:          "key AAAABBBB",
+			expected:       "key ***",
+			expectCommands: "::add-mask::-----BEGIN KEY-----\n::add-mask::AAAABBBB\n::add-mask::-----END KEY-----\n",
+		},
+		{
+			name:           "longer secrets are masked first",
+			secrets:        []string{"token", "token-extended"},
+			input:          "token-extended token",
+			expected:       "*** ***",
+			expectCommands: "::add-mask::token\n::add-mask::token-extended\n",
+		},
+		{
+			name:           "json escaped secret",
+			secrets:        []string{`pa"ss\word`},
+			input:          `{"error": "bad password pa\"ss\\word"}`,
+			expected:       `{"error": "bad password ***"}`,
+			expectCommands: "::add-mask::pa\"ss\\word\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			commands := &bytes.Buffer{}
+			m := NewMasker(commands)
+			m.Add(tc.secrets...)
+			require.Equal(t, tc.expected, m.Scrub(tc.input))
+			require.Equal(t, tc.expectCommands, commands.String())
+		})
+	}
+}
+
+func TestMaskerNil(t *testing.T) {
+	var m *Masker
+	m.Add("password")
+	require.Equal(t, "password", m.Scrub("password"))
+}
+
+func TestMaskerWriter(t *testing.T) {
+	m := NewMasker(nil)
+	out := &bytes.Buffer{}
+	logger, err := New("info", FormatJSON, m.Writer(zapcore.AddSync(out)))
+	require.NoError(t, err)
+
+	// Secrets added after the logger is created are also scrubbed
+	m.Add("s3cr3t-key")
+	logger.Info("connecting", zap.String("api_key", "s3cr3t-key"))
+	require.Contains(t, out.String(), `"api_key":"***"`)
+	require.NotContains(t, out.String(), "s3cr3t-key")
+}
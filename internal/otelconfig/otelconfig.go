@@ -0,0 +1,190 @@
+// Package otelconfig checks the structure of rendered OpenTelemetry
+// collector configurations.
+package otelconfig
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Component sections of a collector configuration
+const (
+	sectionReceivers  = "receivers"
+	sectionProcessors = "processors"
+	sectionExporters  = "exporters"
+	sectionConnectors = "connectors"
+	sectionExtensions = "extensions"
+	sectionService    = "service"
+)
+
+var componentSections = []string{
+	sectionReceivers,
+	sectionProcessors,
+	sectionExporters,
+	sectionConnectors,
+	sectionExtensions,
+}
+
+// Validate checks that the raw configuration is structurally sane:
+//   - components are not defined more than once
+//   - at least one pipeline is defined, and pipelines are not defined more than once
+//   - every pipeline has at least one receiver and one exporter
+//   - pipelines and service extensions only reference defined components
+//
+// All problems found are returned, joined into a single error.
+func Validate(raw string) error {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(raw), doc); err != nil {
+		return fmt.Errorf("failed to unmarshal yaml: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return errors.New("configuration is not a yaml mapping")
+	}
+	root := doc.Content[0]
+
+	var errs []error
+
+	// components maps each section to the IDs defined in it
+	components := make(map[string]map[string]struct{}, len(componentSections))
+	for _, section := range componentSections {
+		ids, err := mappingKeys(lookup(root, section), section)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		components[section] = ids
+	}
+
+	service := lookup(root, sectionService)
+	if service == nil {
+		return errors.Join(append(errs, errors.New("service is not defined"))...)
+	}
+	if service.Kind != yaml.MappingNode {
+		return errors.Join(append(errs, errors.New("service is not a mapping"))...)
+	}
+
+	errs = append(errs, validateReferences(
+		"service extensions", lookup(service, "extensions"), components[sectionExtensions],
+	)...)
+
+	pipelines := lookup(service, "pipelines")
+	ids, err := mappingKeys(pipelines, "service pipelines")
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(ids) == 0 {
+		errs = append(errs, errors.New("service has no pipelines"))
+		return errors.Join(errs...)
+	}
+
+	receivers := union(components[sectionReceivers], components[sectionConnectors])
+	exporters := union(components[sectionExporters], components[sectionConnectors])
+
+	for i := 0; i+1 < len(pipelines.Content); i += 2 {
+		id, pipeline := pipelines.Content[i].Value, pipelines.Content[i+1]
+		name := fmt.Sprintf("pipeline %s", id)
+
+		if pipeline.Kind != yaml.MappingNode {
+			errs = append(errs, fmt.Errorf("%s is not a mapping", name))
+			continue
+		}
+
+		if len(sequence(lookup(pipeline, sectionReceivers))) == 0 {
+			errs = append(errs, fmt.Errorf("%s has no receivers", name))
+		}
+		if len(sequence(lookup(pipeline, sectionExporters))) == 0 {
+			errs = append(errs, fmt.Errorf("%s has no exporters", name))
+		}
+
+		errs = append(errs, validateReferences(name+" receivers", lookup(pipeline, sectionReceivers), receivers)...)
+		errs = append(errs, validateReferences(name+" processors", lookup(pipeline, sectionProcessors), components[sectionProcessors])...)
+		errs = append(errs, validateReferences(name+" exporters", lookup(pipeline, sectionExporters), exporters)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateReferences returns an error for each ID in the list
+// which is not defined, or is listed more than once
+func validateReferences(name string, list *yaml.Node, defined map[string]struct{}) []error {
+	if list != nil && list.Kind != yaml.SequenceNode {
+		return []error{fmt.Errorf("%s is not a list", name)}
+	}
+
+	var errs []error
+	seen := map[string]struct{}{}
+	for _, id := range sequence(list) {
+		if _, ok := seen[id]; ok {
+			errs = append(errs, fmt.Errorf("%s lists %s more than once", name, id))
+			continue
+		}
+		seen[id] = struct{}{}
+
+		if _, ok := defined[id]; !ok {
+			errs = append(errs, fmt.Errorf("%s references %s, which is not defined", name, id))
+		}
+	}
+	return errs
+}
+
+// lookup returns the value of key in the mapping node, or nil
+func lookup(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mappingKeys returns the keys of the mapping node, and an error if a key
+// is defined more than once or the node is not a mapping. A nil or null
+// node has no keys.
+func mappingKeys(node *yaml.Node, name string) (map[string]struct{}, error) {
+	keys := map[string]struct{}{}
+	if node == nil || node.Tag == "!!null" {
+		return keys, nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return keys, fmt.Errorf("%s is not a mapping", name)
+	}
+
+	var errs []error
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if _, ok := keys[key]; ok {
+			errs = append(errs, fmt.Errorf("%s defines %s more than once", name, key))
+			continue
+		}
+		keys[key] = struct{}{}
+	}
+	return keys, errors.Join(errs...)
+}
+
+// sequence returns the scalar values of the sequence node
+func sequence(node *yaml.Node) []string {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	values := make([]string, 0, len(node.Content))
+	for _, n := range node.Content {
+		values = append(values, n.Value)
+	}
+	return values
+}
+
+// union returns the IDs defined in either set
+func union(a, b map[string]struct{}) map[string]struct{} {
+	u := make(map[string]struct{}, len(a)+len(b))
+	for id := range a {
+		u[id] = struct{}{}
+	}
+	for id := range b {
+		u[id] = struct{}{}
+	}
+	return u
+}
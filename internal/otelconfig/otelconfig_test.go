@@ -0,0 +1,145 @@
+package otelconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const validConfig = `receivers:
+  otlp: {}
+  filelog/app: {}
+processors:
+  batch: {}
+exporters:
+  otlp/gateway: {}
+connectors:
+  routing: {}
+extensions:
+  health_check: {}
+service:
+  extensions: [health_check]
+  pipelines:
+    logs/app:
+      receivers: [filelog/app]
+      processors: [batch]
+      exporters: [routing]
+    logs:
+      receivers: [otlp, routing]
+      exporters: [otlp/gateway]
+`
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name     string
+		raw      string
+		expected []string
+	}{
+		{
+			name: "valid",
+			raw:  validConfig,
+		},
+		{
+			name:     "malformed",
+			raw:      "receivers: [",
+			expected: []string{"failed to unmarshal yaml"},
+		},
+		{
+			name:     "not-mapping",
+			raw:      "- receivers\n",
+			expected: []string{"configuration is not a yaml mapping"},
+		},
+		{
+			name:     "missing-service",
+			raw:      "receivers:\n  otlp: {}\n",
+			expected: []string{"service is not defined"},
+		},
+		{
+			name:     "no-pipelines",
+			raw:      "service:\n  pipelines: {}\n",
+			expected: []string{"service has no pipelines"},
+		},
+		{
+			name: "empty-pipeline",
+			raw:  "receivers:\n  otlp: {}\nservice:\n  pipelines:\n    metrics:\n      receivers: [otlp]\n    logs: {}\n",
+			expected: []string{
+				"pipeline metrics has no exporters",
+				"pipeline logs has no receivers",
+				"pipeline logs has no exporters",
+			},
+		},
+		{
+			name: "undefined-components",
+			raw: `receivers:
+  otlp: {}
+exporters:
+  logging: {}
+service:
+  extensions: [pprof]
+  pipelines:
+    traces:
+      receivers: [otlp, jaeger]
+      processors: [batch]
+      exporters: [logging, otlp]
+`,
+			expected: []string{
+				"service extensions references pprof, which is not defined",
+				"pipeline traces receivers references jaeger, which is not defined",
+				"pipeline traces processors references batch, which is not defined",
+				"pipeline traces exporters references otlp, which is not defined",
+			},
+		},
+		{
+			name: "duplicate-ids",
+			raw: `receivers:
+  otlp: {}
+  otlp: {}
+exporters:
+  logging: {}
+service:
+  pipelines:
+    logs:
+      receivers: [otlp]
+      exporters: [logging, logging]
+    logs:
+      receivers: [otlp]
+      exporters: [logging]
+`,
+			expected: []string{
+				"receivers defines otlp more than once",
+				"service pipelines defines logs more than once",
+				"pipeline logs exporters lists logging more than once",
+			},
+		},
+		{
+			name: "wrong-types",
+			raw: `receivers: [otlp]
+service:
+  pipelines:
+    logs:
+      receivers: otlp
+      exporters: [logging]
+`,
+			expected: []string{
+				"receivers is not a mapping",
+				"pipeline logs has no receivers",
+				"pipeline logs receivers is not a list",
+				"pipeline logs exporters references logging, which is not defined",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.raw)
+			if len(tc.expected) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, e := range tc.expected {
+				require.ErrorContains(t, err, e)
+			}
+		})
+	}
+}
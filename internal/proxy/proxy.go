@@ -0,0 +1,61 @@
+// Package proxy resolves the HTTP(S) proxy used by the BindPlane client
+// and git operations.
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Func returns a function suitable for http.Transport's Proxy field. The
+// proxy URL and no proxy list from the config take precedence over the
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+func Func(c config.Proxy) func(*http.Request) (*url.URL, error) {
+	proxyFunc := httpConfig(c).ProxyFunc()
+	return func(r *http.Request) (*url.URL, error) {
+		u, err := proxyFunc(r.URL)
+		return withAuth(c, u, err)
+	}
+}
+
+// URL returns the proxy URL that should be used when connecting to
+// target. A nil URL is returned when the target should not be proxied.
+func URL(c config.Proxy, target string) (*url.URL, error) {
+	t, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+	u, err := httpConfig(c).ProxyFunc()(t)
+	return withAuth(c, u, err)
+}
+
+func httpConfig(c config.Proxy) *httpproxy.Config {
+	conf := httpproxy.FromEnvironment()
+	if c.URL != "" {
+		conf.HTTPProxy = c.URL
+		conf.HTTPSProxy = c.URL
+	}
+	if c.NoProxy != "" {
+		conf.NoProxy = c.NoProxy
+	}
+	return conf
+}
+
+// withAuth sets the configured proxy credentials on the resolved proxy
+// URL. Credentials embedded in the proxy URL are kept when the config
+// does not set a username.
+func withAuth(c config.Proxy, u *url.URL, err error) (*url.URL, error) {
+	if err != nil {
+		return nil, fmt.Errorf("resolve proxy: %w", err)
+	}
+	if u == nil || c.Username == "" {
+		return u, nil
+	}
+	withUser := *u
+	withUser.User = url.UserPassword(c.Username, c.Password)
+	return &withUser, nil
+}
@@ -2,49 +2,308 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"github.com/observiq/bindplane-op-action/internal/proxy"
 )
 
-// CloneRepo clones a repository from the provided URL and branch. If the cloneURL
-// is empty, the function will attempt to clone the repository using a GitHub
-// URL assembled from the GITHUB_ACTOR, GITHUB_REPOSITORY environment variables,
-// and the provided token.
-func CloneRepo(cloneURL, branch, token string) (*git.Repository, error) {
-	// TODO(jsirianni): githubURL should be assembled outside of this package
-	// and passed in. We could remove the need for token, actor, and repo.
-
-	githubActor := os.Getenv("GITHUB_ACTOR")
-	githubRepo := os.Getenv("GITHUB_REPOSITORY")
-	if cloneURL == "" {
-		cloneURL = fmt.Sprintf(
-			"https://%s:%s@github.com/%s.git",
-			githubActor,
-			token,
-			githubRepo,
-		)
-	}
-
-	// TODO(jsirianni): This context sets the clone timeout. This should be
-	// a configurable option.
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*120)
+// CloneURL returns the URL used to clone the repository. If the cloneURL
+// is empty, a GitHub URL is assembled from the GITHUB_ACTOR, GITHUB_REPOSITORY
+// environment variables and the provided token.
+func CloneURL(cloneURL, token string) string {
+	if cloneURL != "" {
+		return cloneURL
+	}
+
+	return fmt.Sprintf(
+		"https://%s:%s@github.com/%s.git",
+		os.Getenv("GITHUB_ACTOR"),
+		token,
+		os.Getenv("GITHUB_REPOSITORY"),
+	)
+}
+
+// AuthURL returns rawURL with the token set as its credentials. The URL
+// is returned unchanged when the token is empty or the URL already
+// contains credentials.
+func AuthURL(rawURL, token string) (string, error) {
+	if token == "" {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("parse repository url: %w", err)
+	}
+	if u.User != nil {
+		return rawURL, nil
+	}
+
+	u.User = url.UserPassword("x-access-token", token)
+	return u.String(), nil
+}
+
+// ProxyOptions returns the go-git proxy options for connecting to rawURL.
+// Empty options are returned when the URL should not be proxied.
+func ProxyOptions(rawURL string, p config.Proxy) (transport.ProxyOptions, error) {
+	u, err := proxy.URL(p, rawURL)
+	if err != nil {
+		return transport.ProxyOptions{}, err
+	}
+	if u == nil {
+		return transport.ProxyOptions{}, nil
+	}
+
+	opts := transport.ProxyOptions{}
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+		u.User = nil
+	}
+	opts.URL = u.String()
+
+	return opts, nil
+}
+
+// DefaultCloneTimeout is the clone timeout used when Options.Timeout is zero
+const DefaultCloneTimeout = 120 * time.Second
+
+// ErrWorkspaceMismatch is returned by OpenWorkspace when the workspace
+// is not a checkout of the requested repository and branch
+var ErrWorkspaceMismatch = errors.New("workspace does not match")
+
+// Options configures how Open obtains a repository
+type Options struct {
+	// URL is the clone URL. See CloneURL.
+	URL string
+
+	// Branch is the branch to check out
+	Branch string
+
+	// Token is used to assemble the clone URL when URL is empty
+	Token string
+
+	// Proxy determines if the clone should go through a proxy
+	Proxy config.Proxy
+
+	// Workspace is the path of an existing checkout, such as
+	// GITHUB_WORKSPACE, which is used instead of cloning when it
+	// has Branch of the same repository checked out
+	Workspace string
+
+	// Depth limits the clone to the given number of commits.
+	// Zero clones the full history.
+	Depth int
+
+	// Timeout limits the duration of the clone. Defaults
+	// to DefaultCloneTimeout.
+	Timeout time.Duration
+}
+
+// Repository is a repository returned by Open. Close must be called
+// to remove the clone directory once the repository is no longer used.
+type Repository struct {
+	*git.Repository
+
+	// Workspace is true when the repository is the existing workspace
+	// checkout rather than a clone
+	Workspace bool
+
+	// dir is the temporary clone directory
+	dir string
+}
+
+// Close removes the clone directory. The workspace is never removed.
+func (r *Repository) Close() error {
+	if r.dir == "" {
+		return nil
+	}
+	if err := os.RemoveAll(r.dir); err != nil {
+		return fmt.Errorf("remove clone directory: %w", err)
+	}
+	return nil
+}
+
+// Open returns the workspace repository when it is a checkout of
+// the requested repository and branch, otherwise the repository
+// is cloned into a temporary directory.
+func Open(opts Options) (*Repository, error) {
+	if opts.Workspace != "" {
+		if r, err := OpenWorkspace(opts.Workspace, CloneURL(opts.URL, opts.Token), opts.Branch); err == nil {
+			return r, nil
+		}
+	}
+
+	return Clone(opts)
+}
+
+// OpenWorkspace opens the checkout at dir. ErrWorkspaceMismatch is
+// returned when its origin remote is not the repository referenced
+// by cloneURL, or when branch is not checked out.
+func OpenWorkspace(dir, cloneURL, branch string) (*Repository, error) {
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open workspace: %w", err)
+	}
+
+	remote, err := r.Remote("origin")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceMismatch, err)
+	}
+
+	owner, name, err := Slug(cloneURL)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := false
+	for _, u := range remote.Config().URLs {
+		o, n, err := Slug(u)
+		if err == nil && strings.EqualFold(o, owner) && strings.EqualFold(n, name) {
+			matches = true
+			break
+		}
+	}
+	if !matches {
+		return nil, fmt.Errorf("%w: origin is not %s/%s", ErrWorkspaceMismatch, owner, name)
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceMismatch, err)
+	}
+	if head.Name() != plumbing.NewBranchReferenceName(branch) {
+		return nil, fmt.Errorf("%w: branch %s is not checked out", ErrWorkspaceMismatch, branch)
+	}
+
+	return &Repository{Repository: r, Workspace: true}, nil
+}
+
+// CloneWorkspace clones the branch checked out in the workspace at dir
+// into a temporary directory, copying its objects instead of fetching
+// them over the network. The workspace is only read, so commits and
+// resets in the clone are not seen by later steps using the workspace.
+// The origin remote of the clone is set to cloneURL. ErrWorkspaceMismatch
+// is returned as by OpenWorkspace.
+func CloneWorkspace(dir, cloneURL, branch string) (*Repository, error) {
+	if _, err := OpenWorkspace(dir, cloneURL, branch); err != nil {
+		return nil, err
+	}
+
+	clone, err := os.MkdirTemp("", "bindplane-op-action-")
+	if err != nil {
+		return nil, fmt.Errorf("create clone directory: %w", err)
+	}
+
+	r, err := git.PlainClone(clone, false, &git.CloneOptions{
+		URL:           dir,
+		SingleBranch:  true,
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+	})
+	if err != nil {
+		_ = os.RemoveAll(clone)
+		return nil, fmt.Errorf("clone workspace: %w", err)
+	}
+
+	if err := r.DeleteRemote("origin"); err != nil {
+		_ = os.RemoveAll(clone)
+		return nil, fmt.Errorf("remove workspace remote: %w", err)
+	}
+	if _, err := r.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{cloneURL}}); err != nil {
+		_ = os.RemoveAll(clone)
+		return nil, fmt.Errorf("set origin remote: %w", err)
+	}
+
+	return &Repository{Repository: r, dir: clone}, nil
+}
+
+// CurrentBranch returns the branch checked out by the repository at or
+// above dir. An empty string is returned when HEAD is detached.
+func CurrentBranch(dir string) (string, error) {
+	r, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", fmt.Errorf("open repository: %w", err)
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return "", fmt.Errorf("get head: %w", err)
+	}
+	if !head.Name().IsBranch() {
+		return "", nil
+	}
+
+	return head.Name().Short(), nil
+}
+
+// Clone clones the branch of the repository into a temporary directory.
+// If the URL is empty, the repository is cloned using a GitHub URL
+// assembled from the GITHUB_ACTOR, GITHUB_REPOSITORY environment
+// variables, and the token.
+func Clone(opts Options) (*Repository, error) {
+	cloneURL := CloneURL(opts.URL, opts.Token)
+
+	proxyOpts, err := ProxyOptions(cloneURL, opts.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("proxy options: %w", err)
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultCloneTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	dir := fmt.Sprintf("%s/%s-%d", os.TempDir(), branch, time.Now().Unix())
+	dir, err := os.MkdirTemp("", "bindplane-op-action-")
+	if err != nil {
+		return nil, fmt.Errorf("create clone directory: %w", err)
+	}
 
-	repo, err := git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
+	r, err := git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
 		URL:           cloneURL,
 		Progress:      os.Stdout,
 		SingleBranch:  true,
-		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		ReferenceName: plumbing.NewBranchReferenceName(opts.Branch),
+		Depth:         opts.Depth,
+		ProxyOptions:  proxyOpts,
 	})
 	if err != nil {
+		_ = os.RemoveAll(dir)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("clone repository: timed out after %s: %w", timeout, ctx.Err())
+		}
 		return nil, fmt.Errorf("clone repository: %w", err)
 	}
 
-	return repo, nil
+	return &Repository{Repository: r, dir: dir}, nil
+}
+
+// Slug returns the owner and name of the repository referenced by
+// cloneURL, such as observIQ and bindplane-op-action for
+// https://github.com/observIQ/bindplane-op-action.git.
+func Slug(cloneURL string) (string, string, error) {
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return "", "", fmt.Errorf("parse clone url: %w", err)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[len(parts)-2] == "" || parts[len(parts)-1] == "" {
+		return "", "", fmt.Errorf("clone url path %s is not of the form owner/repository", u.Path)
+	}
+
+	owner := parts[len(parts)-2]
+	name := strings.TrimSuffix(parts[len(parts)-1], ".git")
+	return owner, name, nil
 }
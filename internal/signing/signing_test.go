@@ -0,0 +1,150 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func newPGPKey(t *testing.T, passphrase string) (string, *openpgp.Entity) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("bot", "", "bot@example.com", nil)
+	require.NoError(t, err)
+
+	if passphrase != "" {
+		require.NoError(t, entity.EncryptPrivateKeys([]byte(passphrase), nil))
+	}
+
+	var b bytes.Buffer
+	w, err := armor.Encode(&b, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivateWithoutSigning(w, nil))
+	require.NoError(t, w.Close())
+
+	return b.String(), entity
+}
+
+func newSSHKey(t *testing.T, passphrase string) (string, ssh.PublicKey) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	var block *pem.Block
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(priv, "")
+	}
+	require.NoError(t, err)
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(block)), sshPub
+}
+
+func TestNew(t *testing.T) {
+	pgpKey, _ := newPGPKey(t, "")
+	encryptedPGPKey, _ := newPGPKey(t, "secret")
+	sshKey, _ := newSSHKey(t, "")
+	encryptedSSHKey, _ := newSSHKey(t, "secret")
+
+	cases := []struct {
+		name       string
+		key        string
+		passphrase string
+		expectErr  string
+	}{
+		{name: "pgp", key: pgpKey},
+		{name: "pgp-encrypted", key: encryptedPGPKey, passphrase: "secret"},
+		{name: "pgp-missing-passphrase", key: encryptedPGPKey, expectErr: "no passphrase"},
+		{name: "pgp-wrong-passphrase", key: encryptedPGPKey, passphrase: "wrong", expectErr: "decrypt pgp key"},
+		{name: "ssh", key: sshKey},
+		{name: "ssh-encrypted", key: encryptedSSHKey, passphrase: "secret"},
+		{name: "ssh-missing-passphrase", key: encryptedSSHKey, expectErr: "read ssh key"},
+		{name: "empty", key: " ", expectErr: "signing key is empty"},
+		{name: "invalid", key: "not a key", expectErr: "read ssh key"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			signer, err := New(tc.key, tc.passphrase)
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, signer)
+		})
+	}
+}
+
+func TestPGPSign(t *testing.T) {
+	key, entity := newPGPKey(t, "")
+	signer, err := New(key, "")
+	require.NoError(t, err)
+
+	message := "tree 1234\n\ncommit message\n"
+	sig, err := signer.Sign(strings.NewReader(message))
+	require.NoError(t, err)
+
+	_, err = openpgp.CheckArmoredDetachedSignature(
+		openpgp.EntityList{entity}, strings.NewReader(message), bytes.NewReader(sig), nil,
+	)
+	require.NoError(t, err)
+}
+
+func TestSSHSign(t *testing.T) {
+	key, pub := newSSHKey(t, "")
+	signer, err := New(key, "")
+	require.NoError(t, err)
+
+	message := "tree 1234\n\ncommit message\n"
+	armored, err := signer.Sign(strings.NewReader(message))
+	require.NoError(t, err)
+
+	s := string(armored)
+	require.True(t, strings.HasPrefix(s, "-----BEGIN SSH SIGNATURE-----\n"))
+	require.True(t, strings.HasSuffix(s, "\n-----END SSH SIGNATURE-----\n"))
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		require.LessOrEqual(t, len(line), 70)
+	}
+
+	encoded := strings.TrimSuffix(strings.TrimPrefix(s, "-----BEGIN SSH SIGNATURE-----\n"), "\n-----END SSH SIGNATURE-----\n")
+	raw, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(encoded, "\n", ""))
+	require.NoError(t, err)
+
+	blob := struct {
+		Magic         [6]byte
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}{}
+	require.NoError(t, ssh.Unmarshal(raw, &blob))
+	require.Equal(t, sshSignatureMagic, string(blob.Magic[:]))
+	require.Equal(t, uint32(sshSignatureVersion), blob.Version)
+	require.Equal(t, pub.Marshal(), blob.PublicKey)
+	require.Equal(t, sshNamespace, blob.Namespace)
+	require.Equal(t, sshHashAlgorithm, blob.HashAlgorithm)
+
+	sig := &ssh.Signature{}
+	require.NoError(t, ssh.Unmarshal(blob.Signature, sig))
+
+	hash := sha512.Sum512([]byte(message))
+	require.NoError(t, pub.Verify(sshSignedData(hash[:]), sig))
+}